@@ -0,0 +1,34 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+
+	"odcread/pkg/bugs"
+	"odcread/pkg/doodle"
+)
+
+// runBugs writes the BUGS model, data and initial values of the first
+// root that has a model to opts.out and prints the names of the files.
+func runBugs(doc *parsedFile, opts options) error {
+	dir := opts.out
+	if dir == "" {
+		dir = "."
+	}
+	for _, s := range doc.roots {
+		a := bugs.FromDocument(s)
+		if a.Model == "" {
+			for _, u := range doodle.Undecoded(s) {
+				fmt.Fprintf(os.Stderr, "Warning: doodle store %s could not be decoded\n", u)
+			}
+			continue
+		}
+		files, err := a.WriteFiles(dir)
+		for _, f := range files {
+			fmt.Fprintln(os.Stdout, f)
+		}
+		return err
+	}
+	return errors.New("no BUGS model found")
+}
@@ -0,0 +1,50 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+
+	"odcread/pkg/doodle"
+)
+
+// runDoodle prints the BUGS code of every graphical model.
+func runDoodle(doc *parsedFile, opts options) error {
+	return writeDoodles(doc, doodle.WriteBUGS)
+}
+
+// runDot prints the graph of every graphical model in the DOT language.
+func runDot(doc *parsedFile, opts options) error {
+	return writeDoodles(doc, doodle.WriteDOT)
+}
+
+// writeDoodles writes the graphical models of every root to stdout with write.
+func writeDoodles(doc *parsedFile, write func(w io.Writer, m *doodle.Model) error) error {
+	n := 0
+	for _, s := range doc.roots {
+		for _, m := range doodle.Models(s) {
+			if n > 0 {
+				os.Stdout.WriteString("\n")
+			}
+			n++
+			if err := write(os.Stdout, m); err != nil {
+				return err
+			}
+		}
+	}
+	undecoded := 0
+	for _, s := range doc.roots {
+		for _, u := range doodle.Undecoded(s) {
+			fmt.Fprintf(os.Stderr, "Warning: doodle store %s could not be decoded\n", u)
+			undecoded++
+		}
+	}
+	if n == 0 && undecoded > 0 {
+		return errors.New("no doodle could be decoded")
+	}
+	if n == 0 {
+		return errors.New("no doodle found")
+	}
+	return nil
+}
@@ -0,0 +1,20 @@
+package main
+
+import (
+	"encoding/json"
+	"os"
+
+	"odcread/pkg/form"
+)
+
+// runForms prints the layouts of the forms in every root as a JSON array.
+func runForms(doc *parsedFile, opts options) error {
+	dialogs := []form.Dialog{}
+	for _, s := range doc.roots {
+		dialogs = append(dialogs, form.Dialogs(s)...)
+	}
+	enc := json.NewEncoder(os.Stdout)
+	enc.SetIndent("", "  ")
+	enc.SetEscapeHTML(false) // Labels mark shortcuts with "&"
+	return enc.Encode(dialogs)
+}
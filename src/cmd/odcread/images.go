@@ -0,0 +1,54 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"odcread/pkg/extract"
+	"odcread/pkg/picture"
+)
+
+// runImages writes the pictures of the document to opts.out and prints
+// the names of the files.
+func runImages(doc *parsedFile, opts options) error {
+	files, err := writeImages(extractAll(doc, opts), title(doc.name), opts.out)
+	for _, f := range files {
+		fmt.Fprintln(os.Stdout, f)
+	}
+	return err
+}
+
+// writeImages writes the pictures of docs to dir as base-1.png,
+// base-2.emf, ... in document order, sets their Image.Src and returns the
+// names of the files written.
+func writeImages(docs []*extract.Document, base, dir string) ([]string, error) {
+	if dir == "" {
+		dir = "."
+	}
+	var files []string
+	n := 0
+	for _, d := range docs {
+		for i := range d.Images {
+			img := &d.Images[i]
+			data, ext, ok := picture.File(img.View)
+			if !ok {
+				fmt.Fprintf(os.Stderr, "Warning: picture at offset %d has no data\n", img.Pos)
+				continue
+			}
+			if n == 0 {
+				if err := os.MkdirAll(dir, 0o755); err != nil {
+					return files, err
+				}
+			}
+			n++
+			name := filepath.Join(dir, fmt.Sprintf("%s-%d%s", base, n, ext))
+			if err := os.WriteFile(name, data, 0o644); err != nil {
+				return files, err
+			}
+			img.Src = filepath.ToSlash(name)
+			files = append(files, name)
+		}
+	}
+	return files, nil
+}
@@ -0,0 +1,48 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+
+	"odcread/pkg/document"
+	"odcread/pkg/dom"
+	"odcread/pkg/store"
+	"odcread/pkg/textview"
+)
+
+// runInfo prints the file type, the page setup and the views of every root.
+func runInfo(doc *parsedFile, opts options) error {
+	w := os.Stdout
+	fmt.Fprintf(w, "File:     %s\n", doc.info.Kind)
+	fmt.Fprintf(w, "Summary:  %s\n", doc.summary)
+	for i, s := range doc.roots {
+		if len(doc.roots) > 1 {
+			fmt.Fprintf(w, "\nRoot %d:\n", i+1)
+		}
+		printInfo(w, s)
+	}
+	return nil
+}
+
+// printInfo prints the page setup, the views and the text size of a root.
+func printInfo(w io.Writer, s store.Store) {
+	fmt.Fprintf(w, "Root:     %s\n", s.GetTypeName())
+
+	view := s
+	if d, ok := s.(*document.StdDocument); ok {
+		fmt.Fprintf(w, "Page:     %s\n", d.GetPage())
+		fmt.Fprintf(w, "Window:   %s\n", d.GetBounds())
+		view = d.GetView()
+	}
+	if view != nil && view != s {
+		fmt.Fprintf(w, "View:     %s\n", view.GetTypeName())
+	}
+	if v, ok := view.(*textview.StdView); ok {
+		fmt.Fprintf(w, "Origin:   %d\n", v.GetOrigin())
+		fmt.Fprintf(w, "Marks:    %s\n", map[bool]string{false: "shown", true: "hidden"}[v.HideMarks()])
+	}
+	if text := textview.MainText(s); text != nil {
+		fmt.Fprintf(w, "Text:     %d characters\n", dom.Build(text).Len)
+	}
+}
@@ -2,62 +2,251 @@
 package main
 
 import (
+	"context"
+	"flag"
 	"fmt"
+	"io"
 	"os"
+	"path/filepath"
+	"strings"
+	"time"
 
 	"odcread/internal/odc"
-	"odcread/pkg/oberon"
+	"odcread/pkg/extract"
 	"odcread/pkg/reader"
 	"odcread/pkg/store"
-	_ "odcread/pkg/typeregister" // Import for side-effect (type registration)
+	"odcread/pkg/typeregister"
 )
 
-const (
-	docTag     = oberon.Integer(0x6F4F4443)
-	docVersion = oberon.Integer(0)
-)
+// options holds the command-line options.
+type options struct {
+	trace     bool
+	lenient   bool
+	timeout   time.Duration
+	force     bool
+	roots     bool
+	summary   bool
+	format    string
+	commander string
+	out       string
+}
+
+// sniffDocument identifies the file and positions it at the root store.
+// Files that are not supported documents are rejected unless opts.force is set.
+func sniffDocument(file *os.File, opts options) (reader.FileInfo, error) {
+	header := make([]byte, reader.SniffLen)
+	n, err := io.ReadFull(file, header)
+	if err != nil && err != io.ErrUnexpectedEOF && err != io.EOF {
+		return reader.FileInfo{}, fmt.Errorf("failed to read document header: %w", err)
+	}
+	fi := reader.Sniff(header[:n])
+
+	root := fi.Root
+	if fi.Kind != reader.Document {
+		if !opts.force {
+			if fi.Root < 0 {
+				return fi, fmt.Errorf("%s", fi)
+			}
+			return fi, fmt.Errorf("%s (use --force to parse it anyway)", fi)
+		}
+		fmt.Fprintf(os.Stderr, "Warning: %s; parsing anyway\n", fi)
+		if root < 0 {
+			root = 8
+		}
+	}
+
+	if _, err := file.Seek(root, io.SeekStart); err != nil {
+		return fi, fmt.Errorf("failed to seek to root store: %w", err)
+	}
+	return fi, nil
+}
+
+// parsedFile is a parsed input file.
+type parsedFile struct {
+	name    string
+	info    reader.FileInfo
+	roots   []store.Store // The root store, followed by further roots if opts.roots is set
+	summary reader.Summary
+}
 
 // importDocument reads and validates an .odc document.
-func importDocument(file *os.File) (store.Store, error) {
+func importDocument(file *os.File, opts options) (*parsedFile, error) {
+	fi, err := sniffDocument(file, opts)
+	if err != nil {
+		return nil, err
+	}
+
 	r := reader.NewReader(file)
+	if opts.trace {
+		r.SetTracer(reader.TracerFunc(traceEvent))
+	}
+	r.SetLenient(opts.lenient)
 
-	// Read and validate document tag
-	tag, err := r.ReadInt()
+	ctx := context.Background()
+	if opts.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, opts.timeout)
+		defer cancel()
+	}
+
+	// Read the root store, and the stores appended to it if requested
+	var roots []store.Store
+	if opts.roots {
+		roots, err = r.ReadRootsContext(ctx)
+	} else {
+		var s store.Store
+		if s, err = r.ReadStoreContext(ctx); err == nil {
+			roots = []store.Store{s}
+		}
+	}
+	for _, d := range r.Damages() {
+		fmt.Fprintf(os.Stderr, "Warning: %s\n", d.String())
+	}
+	if err != nil && len(roots) == 0 {
+		return nil, fmt.Errorf("failed to read root store: %w", err)
+	}
 	if err != nil {
-		return nil, fmt.Errorf("failed to read document tag: %w", err)
+		fmt.Fprintf(os.Stderr, "Warning: %v\n", err)
 	}
 
-	if tag != docTag {
-		return nil, fmt.Errorf("invalid document tag: 0x%X (expected 0x%X)", tag, docTag)
+	sum := r.Summarize(roots...)
+	if opts.summary {
+		fmt.Fprintf(os.Stderr, "Summary: %s\n", sum)
+	} else if !sum.Consumed() && sum.Trailing > 0 {
+		msg := fmt.Sprintf("%d trailing bytes after the root store at offset %d", sum.Trailing, sum.End)
+		if !opts.roots && r.HasNextRoot() {
+			msg += " (they hold another store; use --roots to read it)"
+		}
+		fmt.Fprintf(os.Stderr, "Warning: %s\n", msg)
 	}
 
-	// Read and validate document version
-	version, err := r.ReadInt()
-	if err != nil {
-		return nil, fmt.Errorf("failed to read document version: %w", err)
+	return &parsedFile{name: file.Name(), info: fi, roots: roots, summary: sum}, nil
+}
+
+// traceEvent prints a parse event to stderr, indented by store depth.
+func traceEvent(ev reader.Event) {
+	fmt.Fprintf(os.Stderr, "[TRACE] %s%s\n", strings.Repeat("  ", ev.Depth), ev.String())
+}
+
+// command is a subcommand of odcread.
+type command struct {
+	name    string
+	usage   string
+	run     func(doc *parsedFile, opts options) error
+	doodles bool // Decode WinBUGS doodles (see typeregister.RegisterDoodles)
+}
+
+// commands lists the subcommands; without one, the text is extracted.
+var commands = []command{
+	{"info", "print the document's page setup, views and size", runInfo, false},
+	{"forms", "print the layout of the document's dialogs as JSON", runForms, false},
+	{"commands", "print the commands that follow the document's commanders", runCommands, false},
+	{"images", "write the document's pictures to --out, bitmaps as PNG", runImages, false},
+	{"bugs", "write the document's BUGS model, data and inits to --out", runBugs, true},
+	{"doodle", "print the BUGS code of the document's graphical models", runDoodle, true},
+	{"dot", "print the graphs of the document's graphical models as DOT", runDot, true},
+}
+
+// runText prints the text of every root in the format opts.format.
+func runText(doc *parsedFile, opts options) error {
+	if opts.format == "text" {
+		for i, s := range doc.roots {
+			if i > 0 {
+				fmt.Fprintf(os.Stdout, "\n")
+			}
+			mv := odc.NewMyVisitor(os.Stdout)
+			mv.SetCommander(opts.commander)
+			mv.Visit(s)
+		}
+		return nil
 	}
 
-	if version != docVersion {
-		return nil, fmt.Errorf("unsupported document version: %d (expected %d)", version, docVersion)
+	docs := extractAll(doc, opts)
+	if opts.out != "" {
+		if _, err := writeImages(docs, title(doc.name), opts.out); err != nil {
+			return err
+		}
+	}
+	switch opts.format {
+	case "markdown":
+		return extract.WriteMarkdown(os.Stdout, docs...)
+	default:
+		return extract.WriteHTML(os.Stdout, title(doc.name), docs...)
 	}
+}
 
-	// Read the root store
-	s, err := r.ReadStore()
-	if err != nil {
-		return nil, fmt.Errorf("failed to read root store: %w", err)
+// extractAll extracts the text of every root.
+func extractAll(doc *parsedFile, opts options) []*extract.Document {
+	docs := make([]*extract.Document, len(doc.roots))
+	for i, s := range doc.roots {
+		docs[i] = extract.ExtractOptions(s, extract.Options{Commander: opts.commander})
 	}
+	return docs
+}
 
-	return s, nil
+// runCommands prints the command of every commander, one per line.
+func runCommands(doc *parsedFile, opts options) error {
+	for _, s := range doc.roots {
+		for _, c := range extract.Extract(s).Commands {
+			fmt.Fprintln(os.Stdout, c.Text)
+		}
+	}
+	return nil
+}
+
+// title returns the document title for a file name.
+func title(name string) string {
+	return strings.TrimSuffix(filepath.Base(name), filepath.Ext(name))
 }
 
 func main() {
-	if len(os.Args) < 2 {
-		fmt.Fprintf(os.Stderr, "Usage: %s <file.odc>\n", os.Args[0])
+	run := runText
+	args := os.Args[1:]
+	if len(args) > 0 {
+		for _, c := range commands {
+			if args[0] == c.name {
+				run, args = c.run, args[1:]
+				if c.doodles {
+					typeregister.RegisterDoodles()
+				}
+			}
+		}
+	}
+
+	var opts options
+	flag.BoolVar(&opts.trace, "trace", false, "print parse events to stderr")
+	flag.BoolVar(&opts.lenient, "lenient", false, "skip damaged stores and salvage as much text as possible")
+	flag.DurationVar(&opts.timeout, "timeout", 0, "abort parsing after this duration (e.g. 5s)")
+	flag.BoolVar(&opts.force, "force", false, "parse files that are not BlackBox documents anyway")
+	flag.BoolVar(&opts.roots, "roots", false, "also read the stores that follow the root store")
+	flag.BoolVar(&opts.summary, "summary", false, "print how much of the file was read to stderr")
+	flag.StringVar(&opts.format, "format", "text", "output format: text, markdown or html")
+	flag.StringVar(&opts.commander, "commander", "", "render commanders as this text (e.g. !) instead of dropping them")
+	flag.StringVar(&opts.out, "out", "", "write pictures to this directory; markdown and html output reference them")
+	flag.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Usage: %s [command] [--trace] [--lenient] [--timeout d] [--force] [--roots] [--summary] [--format f] [--commander s] [--out dir] <file.odc>\n", os.Args[0])
+		fmt.Fprintf(os.Stderr, "\nWithout a command, the document's text is printed. Commands:\n")
+		for _, c := range commands {
+			fmt.Fprintf(os.Stderr, "  %-9s %s\n", c.name, c.usage)
+		}
+		fmt.Fprintf(os.Stderr, "\nOptions:\n")
+		flag.PrintDefaults()
+	}
+	flag.CommandLine.Parse(args)
+
+	if flag.NArg() < 1 {
+		flag.Usage()
+		os.Exit(1)
+	}
+	switch opts.format {
+	case "text", "markdown", "html":
+	default:
+		fmt.Fprintf(os.Stderr, "Error: unknown format %q (want text, markdown or html)\n", opts.format)
 		os.Exit(1)
 	}
 
 	// Open the input file
-	file, err := os.Open(os.Args[1])
+	file, err := os.Open(flag.Arg(0))
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "Error opening file: %v\n", err)
 		os.Exit(2)
@@ -65,18 +254,19 @@ func main() {
 	defer file.Close()
 
 	// Import the document
-	s, err := importDocument(file)
+	doc, err := importDocument(file, opts)
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "Error parsing document: %v\n", err)
 		os.Exit(2)
 	}
 
-	if s == nil {
+	if doc.roots[0] == nil {
 		fmt.Fprintf(os.Stderr, "Error: document root is nil\n")
 		os.Exit(2)
 	}
 
-	// Process the document with the visitor
-	visitor := odc.NewMyVisitor()
-	s.Accept(visitor)
+	if err := run(doc, opts); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(2)
+	}
 }
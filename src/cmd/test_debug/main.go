@@ -4,14 +4,29 @@ import (
 	"fmt"
 	"odcread/pkg/alien"
 	"odcread/pkg/reader"
+	"odcread/pkg/store"
+	_ "odcread/pkg/typeregister" // Import for side-effect (type registration)
 	"os"
+	"strings"
 )
 
 func main() {
-	file, _ := os.Open("../../_tests/mini1.odc")
+	path := "../../_tests/mini1.odc"
+	if len(os.Args) > 1 {
+		path = os.Args[1]
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
 	defer file.Close()
 
 	r := reader.NewReader(file)
+	r.SetTracer(reader.TracerFunc(func(ev reader.Event) {
+		fmt.Fprintf(os.Stderr, "%s%s\n", strings.Repeat("  ", ev.Depth), ev.String())
+	}))
 
 	// Read document header
 	tag, _ := r.ReadInt()
@@ -21,7 +36,7 @@ func main() {
 	fmt.Fprintf(os.Stderr, "Version: %d\n", version)
 
 	// Read position before store
-	pos, _ := file.Seek(0, 1)
+	pos := r.Pos()
 	fmt.Fprintf(os.Stderr, "Position before ReadStore: %d (0x%X)\n", pos, pos)
 
 	// Try to read the root store
@@ -33,6 +48,7 @@ func main() {
 
 	fmt.Fprintf(os.Stderr, "Successfully read: %s (ID: %d)\n", s.GetTypeName(), s.GetID())
 	fmt.Fprintf(os.Stderr, "Type path: %v\n", s.GetTypePath())
+	fmt.Fprintf(os.Stderr, "Span: %s\n", s.GetSpan())
 	fmt.Fprintf(os.Stderr, "Actual type: %T\n", s)
 
 	// Check if it's an Alien with direct type assertion
@@ -40,7 +56,16 @@ func main() {
 		comps := a.GetComponents()
 		fmt.Fprintf(os.Stderr, "✓ IS ALIEN with %d components:\n", len(comps))
 		for i, comp := range comps {
-			fmt.Fprintf(os.Stderr, "  [%d] %s\n", i, comp.String())
+			span := store.Span{}
+			switch c := comp.(type) {
+			case *alien.AlienPiece:
+				span = c.GetSpan()
+			case *alien.AlienPart:
+				if c.GetStore() != nil {
+					span = c.GetStore().GetSpan()
+				}
+			}
+			fmt.Fprintf(os.Stderr, "  [%d] %s %s\n", i, comp.String(), span)
 		}
 	} else {
 		fmt.Fprintf(os.Stderr, "✗ NOT AN ALIEN - unexpected!\n")
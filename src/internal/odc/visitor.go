@@ -2,39 +2,65 @@ package odc
 
 import (
 	"fmt"
+	"io"
 	"os"
 
+	"odcread/pkg/commander"
 	"odcread/pkg/encoding"
+	"odcread/pkg/fold"
 	"odcread/pkg/store"
+	"odcread/pkg/tabview"
 	"odcread/pkg/textmodel"
+	"odcread/pkg/visitor"
 )
 
 // MyVisitor - concrete visitor implementation for text extraction
 type MyVisitor struct {
+	visitor.Base
+	out          io.Writer
 	contextStack []Context
-	visited      map[store.Store]bool // Track visited stores by pointer to prevent cycles
+	commander    string
+	traversal    *visitor.Traversal
 }
 
-func NewMyVisitor() *MyVisitor {
-	return &MyVisitor{
+// NewMyVisitor creates a visitor that writes the text of top-level contexts to out.
+// Use its Visit method to traverse a document.
+func NewMyVisitor(out io.Writer) *MyVisitor {
+	mv := &MyVisitor{
+		out:          out,
 		contextStack: make([]Context, 0),
-		visited:      make(map[store.Store]bool),
 	}
+	mv.traversal = visitor.NewTraversal(mv)
+	return mv
 }
 
-func (mv *MyVisitor) PartStart() {
+// Visit writes the text of the tree rooted at s. The tabs of tab views are
+// visited by the same traversal, so each store is written at most once.
+func (mv *MyVisitor) Visit(s store.Store) bool {
+	return mv.traversal.Visit(s)
+}
+
+// SetCommander sets the text that stands for DevCommanders commanders,
+// e.g. "!". By default, commanders are dropped.
+func (mv *MyVisitor) SetCommander(token string) {
+	mv.commander = token
+}
+
+func (mv *MyVisitor) VisitTextModel(m *textmodel.StdTextModel) visitor.Action {
 	mv.contextStack = append(mv.contextStack, &PartContext{})
+	return visitor.Continue
 }
 
-func (mv *MyVisitor) PartEnd() {
+func (mv *MyVisitor) LeaveTextModel(m *textmodel.StdTextModel) {
 	mv.terminateContext()
 }
 
-func (mv *MyVisitor) FoldLeft(collapsed bool) {
-	mv.contextStack = append(mv.contextStack, NewFoldContext(collapsed))
+func (mv *MyVisitor) VisitFold(f *fold.Fold) visitor.Action {
+	mv.contextStack = append(mv.contextStack, NewFoldContext(f.IsCollapsed()))
+	return visitor.Continue
 }
 
-func (mv *MyVisitor) FoldRight() {
+func (mv *MyVisitor) LeaveFold(f *fold.Fold) {
 	mv.terminateContext()
 }
 
@@ -48,44 +74,76 @@ func (mv *MyVisitor) terminateContext() {
 	mv.contextStack = mv.contextStack[:top]
 
 	if len(mv.contextStack) == 0 {
-		// Top-level context - print to stdout
-		fmt.Println(ctx.GetPlainText())
+		// Top-level context - print to the output
+		fmt.Fprintln(mv.out, ctx.GetPlainText())
 	} else {
 		// Nested context - add to parent
 		mv.contextStack[len(mv.contextStack)-1].AddPiece(ctx.GetPlainText())
 	}
 }
 
-func (mv *MyVisitor) TextShortPiece(piece interface{}) {
-	if sp, ok := piece.(*textmodel.ShortPiece); ok {
-		str, err := encoding.ConvertLatin1(sp.GetBuffer())
-		if err != nil {
-			fmt.Fprintf(os.Stderr, "Warning: failed to convert short piece: %v\n", err)
-			return
-		}
-		if len(mv.contextStack) > 0 {
-			mv.contextStack[len(mv.contextStack)-1].AddPiece(str)
-		}
+func (mv *MyVisitor) VisitShortPiece(sp *textmodel.ShortPiece) visitor.Action {
+	str, err := encoding.ConvertLatin1(sp.GetBuffer())
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: failed to convert short piece: %v\n", err)
+		return visitor.Continue
+	}
+	if len(mv.contextStack) > 0 {
+		mv.contextStack[len(mv.contextStack)-1].AddPiece(str)
 	}
+	return visitor.Continue
 }
 
-func (mv *MyVisitor) TextLongPiece(piece interface{}) {
-	if lp, ok := piece.(*textmodel.LongPiece); ok {
-		str, err := encoding.ConvertUCS2(lp.GetBuffer())
-		if err != nil {
-			fmt.Fprintf(os.Stderr, "Warning: failed to convert long piece: %v\n", err)
-			return
-		}
-		if len(mv.contextStack) > 0 {
-			mv.contextStack[len(mv.contextStack)-1].AddPiece(str)
+func (mv *MyVisitor) VisitLongPiece(lp *textmodel.LongPiece) visitor.Action {
+	str, err := encoding.ConvertUCS2(lp.GetBuffer())
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: failed to convert long piece: %v\n", err)
+		return visitor.Continue
+	}
+	if len(mv.contextStack) > 0 {
+		mv.contextStack[len(mv.contextStack)-1].AddPiece(str)
+	}
+	return visitor.Continue
+}
+
+// VisitViewPiece renders views that stand for text, such as date stamps,
+// and commanders in place.
+func (mv *MyVisitor) VisitViewPiece(vp *textmodel.ViewPiece) visitor.Action {
+	if len(mv.contextStack) == 0 {
+		return visitor.Continue
+	}
+	switch v := vp.GetView().(type) {
+	case *commander.StdView:
+		if mv.commander != "" {
+			mv.contextStack[len(mv.contextStack)-1].AddPiece(mv.commander)
 		}
+	case store.InlineText:
+		mv.contextStack[len(mv.contextStack)-1].AddPiece(v.InlineText())
 	}
+	return visitor.Continue
 }
 
-func (mv *MyVisitor) ShouldVisit(s store.Store) bool {
-	if mv.visited[s] {
-		return false
+// VisitStore renders the tabs of tab views as sections headed by their
+// labels in brackets, e.g. "[Options]". A tab whose view was reached
+// before (e.g. shared with another tab or linking back to the tab view)
+// shows only its label.
+func (mv *MyVisitor) VisitStore(s store.Store) visitor.Action {
+	tv, ok := s.(*tabview.View)
+	if !ok {
+		return visitor.Continue
+	}
+	for _, t := range tv.GetTabs() {
+		if len(mv.contextStack) == 0 {
+			fmt.Fprintf(mv.out, "[%s]\n", t.Label)
+		} else {
+			mv.contextStack[len(mv.contextStack)-1].AddPiece(fmt.Sprintf("\n[%s]\n", t.Label))
+		}
+		if !mv.traversal.Visit(t.View) {
+			return visitor.Stop
+		}
+	}
+	if len(mv.contextStack) > 0 {
+		mv.contextStack[len(mv.contextStack)-1].AddPiece("\n")
 	}
-	mv.visited[s] = true
-	return true
+	return visitor.SkipChildren
 }
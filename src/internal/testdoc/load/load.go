@@ -0,0 +1,99 @@
+// Package load reads test documents for the decoder tests. It is separate
+// from package testdoc, which the reader's own tests import.
+package load
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"odcread/pkg/alien"
+	"odcread/pkg/reader"
+	"odcread/pkg/store"
+	"odcread/pkg/textmodel"
+	"odcread/pkg/visitor"
+)
+
+// Store reads the root store of doc, failing the test on error.
+func Store(t testing.TB, doc []byte) store.Store {
+	t.Helper()
+	s, err := reader.NewReader(bytes.NewReader(doc)).ReadStore()
+	if err != nil {
+		t.Fatalf("ReadStore failed: %v", err)
+	}
+	return s
+}
+
+// View returns the view of piece i of the text model s.
+func View(t testing.TB, s store.Store, i int) store.Store {
+	t.Helper()
+	m, ok := s.(*textmodel.StdTextModel)
+	if !ok {
+		t.Fatalf("Expected a text model, got %v", s)
+	}
+	if pieces := m.GetPieces(); i >= len(pieces) {
+		t.Fatalf("Expected at least %d pieces, got %d", i+1, len(pieces))
+	}
+	p, ok := m.GetPieces()[i].(*textmodel.ViewPiece)
+	if !ok {
+		t.Fatalf("Expected piece %d to be a view, got %v", i, m.GetPieces()[i])
+	}
+	return p.GetView()
+}
+
+// CorpusDir returns the directory of the .odc test corpus: $ODC_CORPUS if
+// set, or the _tests directory at the root of the repository.
+func CorpusDir() string {
+	if dir := os.Getenv("ODC_CORPUS"); dir != "" {
+		return dir
+	}
+	_, file, _, _ := runtime.Caller(0)
+	return filepath.Join(filepath.Dir(file), "..", "..", "..", "..", "_tests")
+}
+
+// Corpus reads the documents of the test corpus, which were written by
+// BlackBox, and returns the stores of type typeName decoded from them.
+// A store of that type that was read as an alien because its decoder
+// failed, or a document that cannot be read, fails the test. The test is
+// skipped if the corpus holds no store of the type.
+func Corpus(t *testing.T, typeName string) []store.Store {
+	t.Helper()
+	files, _ := filepath.Glob(filepath.Join(CorpusDir(), "*.odc"))
+	if len(files) == 0 {
+		t.Skip("test corpus not available")
+	}
+
+	var found []store.Store
+	for _, name := range files {
+		data, err := os.ReadFile(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		fi := reader.Sniff(data)
+		if fi.Kind != reader.Document {
+			continue
+		}
+		r := reader.NewReader(bytes.NewReader(data[fi.Root:]))
+		s, err := r.ReadStore()
+		if err != nil {
+			t.Errorf("%s: ReadStore failed: %v", filepath.Base(name), err)
+			continue
+		}
+		for _, d := range r.Damages() {
+			if len(d.Path) > 0 && d.Path[0] == typeName {
+				t.Errorf("%s: %s", filepath.Base(name), d.String())
+			}
+		}
+		for _, a := range visitor.Find(s, visitor.Type(typeName)) {
+			if _, ok := a.Store().(*alien.Alien); !ok {
+				found = append(found, a.Store())
+			}
+		}
+	}
+	if len(found) == 0 {
+		t.Skipf("no %s in the test corpus", typeName)
+	}
+	return found
+}
@@ -0,0 +1,581 @@
+// Package testdoc encodes small BlackBox documents for tests.
+package testdoc
+
+import (
+	"bytes"
+	"encoding/binary"
+	"unicode/utf16"
+
+	"odcread/pkg/store"
+)
+
+// Header is the document tag and version that precede the root store in a file.
+var Header = []byte{0x43, 0x44, 0x4F, 0x6F, 0, 0, 0, 0}
+
+// TextModelPath is the type path of TextModels.StdModel.
+var TextModelPath = []string{
+	"TextModels.StdModelDesc", "TextModels.ModelDesc", "Containers.ModelDesc",
+	"Models.ModelDesc", "Stores.ElemDesc", "Stores.StoreDesc",
+}
+
+// FoldPath is the type path of StdFolds.Fold.
+var FoldPath = []string{"StdFolds.FoldDesc", "Views.ViewDesc", "Stores.StoreDesc"}
+
+// StdViewPath is the type path of TextViews.StdView.
+var StdViewPath = []string{
+	"TextViews.StdViewDesc", "TextViews.ViewDesc", "Containers.ViewDesc",
+	"Views.ViewDesc", "Stores.StoreDesc",
+}
+
+// DocumentModelPath is the type path of Documents.Model.
+var DocumentModelPath = []string{
+	"Documents.ModelDesc", "Containers.ModelDesc", "Models.ModelDesc",
+	"Stores.ElemDesc", "Stores.StoreDesc",
+}
+
+// StdDocumentPath is the type path of Documents.StdDocument.
+var StdDocumentPath = []string{
+	"Documents.StdDocumentDesc", "Documents.DocumentDesc", "Containers.ViewDesc",
+	"Views.ViewDesc", "Stores.StoreDesc",
+}
+
+// LE appends the little-endian encoding of v to buf.
+func LE(buf *bytes.Buffer, v int32) {
+	binary.Write(buf, binary.LittleEndian, v)
+}
+
+// Store encodes a STORE or ELEM with the given type path and content.
+// downOff is the offset of the first embedded store within content (-1 for none).
+func Store(marker byte, path []string, content []byte, downOff int) []byte {
+	buf := new(bytes.Buffer)
+	buf.WriteByte(marker)
+	for i, name := range path {
+		if i == len(path)-1 {
+			buf.WriteByte(byte(store.NEWBASE))
+		} else {
+			buf.WriteByte(byte(store.NEWEXT))
+		}
+		buf.WriteString(name)
+		buf.WriteByte(0)
+	}
+	LE(buf, 0) // comment
+	LE(buf, 0) // next
+	if downOff >= 0 {
+		LE(buf, int32(4+downOff))
+	} else {
+		LE(buf, 0)
+	}
+	LE(buf, int32(len(content)))
+	buf.Write(content)
+	return buf.Bytes()
+}
+
+// Nil encodes a NIL store; next is the distance from its end to the next store.
+func Nil(next int32) []byte {
+	buf := new(bytes.Buffer)
+	buf.WriteByte(byte(store.NIL))
+	LE(buf, 0)
+	LE(buf, next)
+	return buf.Bytes()
+}
+
+// Text is an element of a text model: a string or an embedded view.
+type Text interface{}
+
+// View is a view embedded in a text model.
+type View struct {
+	Width, Height int32
+	Store         []byte // Encoded view store
+}
+
+// Raw is store content that is not an embedded store.
+type Raw []byte
+
+// Content concatenates raw bytes and encoded stores ([]byte) into the
+// content of a store, linking the stores through their next fields.
+// downOff is the offset of the first store, or -1 if there is none.
+func Content(parts ...interface{}) (content []byte, downOff int) {
+	var buf bytes.Buffer
+	var offs, nexts []int // Offsets of the stores and of their next fields
+	for _, p := range parts {
+		switch p := p.(type) {
+		case Raw:
+			buf.Write(p)
+		case []byte:
+			offs = append(offs, buf.Len())
+			nexts = append(nexts, buf.Len()+nextField(p))
+			buf.Write(p)
+		}
+	}
+
+	raw := buf.Bytes()
+	for k := 0; k+1 < len(offs); k++ {
+		binary.LittleEndian.PutUint32(raw[nexts[k]:], uint32(offs[k+1]-(nexts[k]+4)))
+	}
+	if len(offs) == 0 {
+		return raw, -1
+	}
+	return raw, offs[0]
+}
+
+// Long is a string stored as a long piece (UTF-16) by TextModel.
+type Long string
+
+// TextModel encodes a StdTextModel with the given strings and views.
+// Strings are stored as short pieces and Long strings as long pieces; all
+// pieces share NIL attributes.
+// The attributes and the views are chained as the model's embedded stores.
+func TextModel(elems ...Text) []byte {
+	parts := []interface{}{Raw{0, 0, 0, 0, 0, 0}, Raw{0, 0, 0, 0}} // versions, metaLen
+	var pieces bytes.Buffer
+	for i, e := range elems {
+		parts = append(parts, Raw{0}) // ano
+		if i == 0 {
+			parts = append(parts, Nil(0))
+		}
+		var desc bytes.Buffer
+		switch e := e.(type) {
+		case string:
+			LE(&desc, int32(len(e)))
+			parts = append(parts, Raw(desc.Bytes()))
+			pieces.WriteString(e)
+		case Long:
+			chars := utf16.Encode([]rune(string(e)))
+			LE(&desc, -2*int32(len(chars)))
+			parts = append(parts, Raw(desc.Bytes()))
+			binary.Write(&pieces, binary.LittleEndian, chars)
+		case View:
+			LE(&desc, 0)
+			LE(&desc, e.Width)
+			LE(&desc, e.Height)
+			parts = append(parts, Raw(desc.Bytes()), e.Store)
+			pieces.WriteByte(0)
+		}
+	}
+	parts = append(parts, Raw{0xFF}, Raw(pieces.Bytes()))
+
+	content, downOff := Content(parts...)
+	return Store(byte(store.ELEM), TextModelPath, content, downOff)
+}
+
+// nextField returns the offset of the next field in an encoded store.
+func nextField(enc []byte) int {
+	switch enc[0] {
+	case byte(store.NIL):
+		return 5
+	case byte(store.LINK), byte(store.NEWLINK):
+		return 9 // After the id and the comment
+	}
+	i := 1
+	for i < len(enc) {
+		tag := enc[i]
+		i++
+		if tag == byte(store.OLDTYPE) {
+			i += 4
+			break
+		}
+		i += bytes.IndexByte(enc[i:], 0) + 1
+		if tag == byte(store.NEWBASE) {
+			break
+		}
+	}
+	return i + 4 // comment
+}
+
+// Fold encodes a fold with the given label and hidden text model, or a
+// closing fold if hidden is nil.
+func Fold(collapsed bool, label string, hidden []byte) []byte {
+	content := new(bytes.Buffer)
+	content.Write([]byte{0, 0, 0}) // store, view and fold versions
+	content.Write([]byte{1, 0})    // leftSide
+	if collapsed {
+		content.Write([]byte{0, 0})
+	} else {
+		content.Write([]byte{1, 0})
+	}
+	content.WriteString(label)
+	content.WriteByte(0)
+	downOff := content.Len()
+	if hidden == nil {
+		hidden = Nil(0)
+	}
+	content.Write(hidden)
+	return Store(byte(store.STORE), FoldPath, content.Bytes(), downOff)
+}
+
+// Opaque encodes a store of an unregistered type with empty content.
+func Opaque(name string) []byte {
+	return Store(byte(store.STORE), []string{name, "Stores.StoreDesc"}, nil, -1)
+}
+
+// StdView encodes a text view of model with no controller, an opaque
+// default ruler and attributes, and the given scroll origin.
+func StdView(model []byte, org int32, hideMarks bool) []byte {
+	var tail bytes.Buffer
+	LE(&tail, org)
+	if hideMarks {
+		tail.WriteByte(1)
+	} else {
+		tail.WriteByte(0)
+	}
+	content, downOff := Content(
+		Raw{0, 0, 0}, // store, view and container versions
+		model, Nil(0),
+		Raw{0}, // text view version
+		Opaque("TextRulers.StdRulerDesc"), Opaque("TextModels.AttributesDesc"),
+		Raw(tail.Bytes()),
+	)
+	return Store(byte(store.STORE), StdViewPath, content, downOff)
+}
+
+// Document encodes a StdDocument showing view, with an opaque controller.
+// page holds the page width and height, the printable area (l, t, r, b)
+// and the view bounds (l, t, r, b).
+func Document(view []byte, page [10]int32, decorate bool) []byte {
+	var bounds, setup bytes.Buffer
+	for _, v := range page[6:] {
+		LE(&bounds, v)
+	}
+	for _, v := range page[:6] {
+		LE(&setup, v)
+	}
+	if decorate {
+		setup.WriteByte(1)
+	} else {
+		setup.WriteByte(0)
+	}
+
+	content, downOff := Content(
+		Raw{0, 0, 0, 0, 0}, // store, elem, model, container and document model versions
+		view, Raw(bounds.Bytes()),
+	)
+	model := Store(byte(store.ELEM), DocumentModelPath, content, downOff)
+
+	content, downOff = Content(
+		Raw{0, 0, 0}, // store, view and container versions
+		model, Opaque("Documents.ControllerDesc"),
+		Raw{0}, // document version
+		Raw(setup.Bytes()),
+	)
+	return Store(byte(store.STORE), StdDocumentPath, content, downOff)
+}
+
+// RulerTab is a tab stop of Ruler.
+type RulerTab struct {
+	Stop int32
+	Type uint32
+}
+
+// Ruler encodes a StdRuler with a style holding the given margins, options
+// and typed tab stops.
+func Ruler(first, left, right int32, opts uint32, tabs ...RulerTab) []byte {
+	var attr bytes.Buffer
+	attr.Write([]byte{0, 2}) // store and attributes versions
+	for _, v := range []int32{first, left, right, 0, 0, 0, 0} {
+		LE(&attr, v)
+	}
+	LE(&attr, int32(opts))
+	binary.Write(&attr, binary.LittleEndian, int16(len(tabs)))
+	for _, t := range tabs {
+		LE(&attr, t.Stop)
+	}
+	for _, t := range tabs {
+		LE(&attr, int32(t.Type))
+	}
+	attrs := Store(byte(store.STORE), []string{"TextRulers.AttributesDesc", "Stores.StoreDesc"}, attr.Bytes(), -1)
+
+	content, downOff := Content(Raw{0, 0, 0, 0}, attrs, Raw{0}) // store, elem, model and style versions
+	style := Store(byte(store.ELEM), []string{
+		"TextRulers.StdStyleDesc", "TextRulers.StyleDesc", "Models.ModelDesc", "Stores.ElemDesc", "Stores.StoreDesc",
+	}, content, downOff)
+
+	content, downOff = Content(Raw{0, 0, 0}, style, Raw{0}) // store, view and ruler versions
+	return Store(byte(store.STORE), []string{
+		"TextRulers.StdRulerDesc", "TextRulers.RulerDesc", "Views.ViewDesc", "Stores.StoreDesc",
+	}, content, downOff)
+}
+
+// Link encodes an opening StdLinks.Link with the given command, or a
+// closing one if cmd is "".
+func Link(cmd string) []byte {
+	return linkView("StdLinks.LinkDesc", cmd, true)
+}
+
+// Target encodes an opening StdLinks.Target with the given identifier, or
+// a closing one if ident is "".
+func Target(ident string) []byte {
+	return linkView("StdLinks.TargetDesc", ident, false)
+}
+
+func linkView(name, text string, isLink bool) []byte {
+	var content bytes.Buffer
+	content.Write([]byte{0, 0}) // store and view versions
+	if isLink {
+		content.WriteByte(1)
+	} else {
+		content.WriteByte(0)
+	}
+	if text != "" {
+		content.WriteByte(1)
+	} else {
+		content.WriteByte(0)
+	}
+	LE(&content, int32(len(text)))
+	if text != "" {
+		content.WriteString(text)
+		content.WriteByte(0)
+		if isLink {
+			LE(&content, 2) // close: never
+		}
+	}
+	return Store(byte(store.STORE), []string{name, "Views.ViewDesc", "Stores.StoreDesc"}, content.Bytes(), -1)
+}
+
+// StampEntry is a save recorded by Stamp.
+type StampEntry struct {
+	Day, Time int32 // Days since 1 Jan 1; minute + 64 * hour
+	Comment   string
+}
+
+// Stamp encodes a version 2 StdStamps.StdView with the given history,
+// newest entry first.
+func Stamp(history ...StampEntry) []byte {
+	var content bytes.Buffer
+	content.Write([]byte{0, 0, 2}) // store, view and stamp versions
+	LE(&content, int32(len(history)))
+	for i, e := range history {
+		LE(&content, int32(1000+i)) // fingerprint
+		LE(&content, int32(len(history)-i))
+		LE(&content, e.Day)
+		LE(&content, e.Time)
+		content.WriteString(e.Comment)
+		content.WriteByte(0)
+	}
+	return Store(byte(store.STORE), []string{"StdStamps.StdViewDesc", "Views.ViewDesc", "Stores.StoreDesc"}, content.Bytes(), -1)
+}
+
+// String16 appends s as a null-terminated string of 16-bit characters.
+func String16(buf *bytes.Buffer, s string) {
+	for _, r := range s {
+		binary.Write(buf, binary.LittleEndian, uint16(r))
+	}
+	buf.Write([]byte{0, 0})
+}
+
+// Control encodes a version 1 control of the given kind (e.g. "PushButton")
+// with the default font and no options, followed by the integers data of
+// its type (e.g. min, max and inc for "UpDownField").
+func Control(kind, link, label, guard, notifier string, data ...int32) []byte {
+	var content bytes.Buffer
+	content.Write([]byte{0, 0, 1}) // store, view and control versions
+	for _, s := range []string{link, label, guard, notifier} {
+		String16(&content, s)
+	}
+	LE(&content, 0)                         // level
+	content.Write([]byte{0, 0, 0, 0, 0, 0}) // customFont, opt[0..4]
+	content.WriteByte(0)                    // control type version
+	for _, v := range data {
+		LE(&content, v)
+	}
+	path := []string{"Controls." + kind + "Desc", "Controls.ControlDesc", "Views.ViewDesc", "Stores.StoreDesc"}
+	return Store(byte(store.STORE), path, content.Bytes(), -1)
+}
+
+// FormItem is a view of Form with its bounds (l, t, r, b).
+type FormItem struct {
+	View   []byte
+	Bounds [4]int32
+}
+
+// Form encodes a FormModels.StdModel holding the given views.
+func Form(items ...FormItem) []byte {
+	parts := []interface{}{Raw{0, 0, 0, 0, 0}} // store, elem, model, container and form model versions
+	for _, it := range items {
+		var bounds bytes.Buffer
+		for _, v := range it.Bounds {
+			LE(&bounds, v)
+		}
+		parts = append(parts, it.View, Raw(bounds.Bytes()))
+	}
+	parts = append(parts, Nil(0))
+	content, downOff := Content(parts...)
+	path := []string{
+		"FormModels.StdModelDesc", "FormModels.ModelDesc", "Containers.ModelDesc",
+		"Models.ModelDesc", "Stores.ElemDesc", "Stores.StoreDesc",
+	}
+	return Store(byte(store.ELEM), path, content, downOff)
+}
+
+// Commander encodes a DevCommanders.StdView.
+func Commander() []byte {
+	path := []string{"DevCommanders.StdViewDesc", "DevCommanders.ViewDesc", "Views.ViewDesc", "Stores.StoreDesc"}
+	return Store(byte(store.STORE), path, []byte{0, 0, 0}, -1) // store, view and commander versions
+}
+
+// CommanderEnd encodes a DevCommanders.StdEndView.
+func CommanderEnd() []byte {
+	path := []string{"DevCommanders.StdEndViewDesc", "DevCommanders.EndViewDesc", "Views.ViewDesc", "Stores.StoreDesc"}
+	return Store(byte(store.STORE), path, []byte{0, 0, 0}, -1)
+}
+
+// Picture encodes a HostPictures.StdView of the given type holding data.
+func Picture(typ byte, data []byte) []byte {
+	var content bytes.Buffer
+	content.Write([]byte{0, 0, 0, typ}) // store, view and picture versions, type
+	LE(&content, 36000)                 // w
+	LE(&content, 36000)                 // h
+	content.WriteByte(1)                // mode: fit
+	LE(&content, int32(len(data)))
+	content.Write(data)
+	path := []string{"HostPictures.StdViewDesc", "Views.ViewDesc", "Stores.StoreDesc"}
+	return Store(byte(store.STORE), path, content.Bytes(), -1)
+}
+
+// Tab is a tab of TabView.
+type Tab struct {
+	Label string
+	View  []byte
+}
+
+// TabView encodes a version 1 StdTabViews.View with the given tabs and
+// the first tab selected.
+func TabView(tabs ...Tab) []byte {
+	var n bytes.Buffer
+	LE(&n, int32(len(tabs)))
+	parts := []interface{}{Raw{0, 0, 1}, Raw(n.Bytes())} // store, view and tab view versions
+	for _, t := range tabs {
+		var label bytes.Buffer
+		String16(&label, t.Label)
+		parts = append(parts, Raw(label.Bytes()), t.View)
+	}
+	var tail bytes.Buffer
+	LE(&tail, 0)        // selected tab
+	String16(&tail, "") // notifier
+	parts = append(parts, Raw(tail.Bytes()))
+	content, downOff := Content(parts...)
+	path := []string{"StdTabViews.ViewDesc", "Views.ViewDesc", "Stores.StoreDesc"}
+	return Store(byte(store.STORE), path, content, downOff)
+}
+
+// DoodleNode is a node of Doodle.
+type DoodleNode struct {
+	Kind    byte // 0 stochastic, 1 logical, 2 constant
+	Name    string
+	X, Y    int32
+	Density string
+	Params  [][2]string // Name and value
+	Value   string
+	Link    string
+	Bounds  [2]string // Lower and upper; version 1
+}
+
+// DoodlePlate is a plate of Doodle with its bounds (l, t, r, b).
+type DoodlePlate struct {
+	Index, From, To string
+	Bounds          [4]int32
+}
+
+// sstring appends a null-terminated string of 8-bit characters to buf.
+func sstring(buf *bytes.Buffer, s string) {
+	buf.WriteString(s)
+	buf.WriteByte(0)
+}
+
+// Doodle encodes a DoodleModels.Model with the given nodes, edges (parent
+// and child node index) and plates, in the layout that package doodle
+// assumes. The layout is not verified against WinBUGS (see package doodle).
+func Doodle(nodes []DoodleNode, edges [][2]int32, plates []DoodlePlate) []byte {
+	var n bytes.Buffer
+	LE(&n, int32(len(nodes)))
+	parts := []interface{}{Raw{0, 0, 0, 0}, Raw(n.Bytes())} // store, elem, model and doodle model versions
+	for _, nd := range nodes {
+		var c bytes.Buffer
+		c.Write([]byte{0, 1, nd.Kind}) // store and node versions
+		sstring(&c, nd.Name)
+		LE(&c, nd.X)
+		LE(&c, nd.Y)
+		sstring(&c, nd.Density)
+		LE(&c, int32(len(nd.Params)))
+		for _, p := range nd.Params {
+			sstring(&c, p[0])
+			sstring(&c, p[1])
+		}
+		for _, s := range []string{nd.Value, nd.Link, nd.Bounds[0], nd.Bounds[1]} {
+			sstring(&c, s)
+		}
+		parts = append(parts, Store(byte(store.STORE), []string{"DoodleNodes.NodeDesc", "Stores.StoreDesc"}, c.Bytes(), -1))
+	}
+	var e bytes.Buffer
+	LE(&e, int32(len(edges)))
+	for _, ed := range edges {
+		LE(&e, ed[0])
+		LE(&e, ed[1])
+	}
+	LE(&e, int32(len(plates)))
+	parts = append(parts, Raw(e.Bytes()))
+	for _, p := range plates {
+		var c bytes.Buffer
+		c.Write([]byte{0, 0}) // store and plate versions
+		for _, s := range []string{p.Index, p.From, p.To} {
+			sstring(&c, s)
+		}
+		for _, v := range p.Bounds {
+			LE(&c, v)
+		}
+		parts = append(parts, Store(byte(store.STORE), []string{"DoodlePlates.PlateDesc", "Stores.StoreDesc"}, c.Bytes(), -1))
+	}
+	content, downOff := Content(parts...)
+	path := []string{"DoodleModels.ModelDesc", "Models.ModelDesc", "Stores.ElemDesc", "Stores.StoreDesc"}
+	return Store(byte(store.ELEM), path, content, downOff)
+}
+
+// DoodleView encodes a DoodleViews.View showing model.
+func DoodleView(model []byte) []byte {
+	content, downOff := Content(Raw{0, 0, 0}, model) // store, view and doodle view versions
+	path := []string{"DoodleViews.ViewDesc", "Views.ViewDesc", "Stores.StoreDesc"}
+	return Store(byte(store.STORE), path, content, downOff)
+}
+
+// Clock encodes a StdClocks.StdView with the given display format.
+func Clock(format byte) []byte {
+	path := []string{"StdClocks.StdViewDesc", "Views.ViewDesc", "Stores.StoreDesc"}
+	return Store(byte(store.STORE), path, []byte{0, 0, 0, format}, -1) // store, view and clock versions
+}
+
+// HeaderView encodes a version 1 StdHeaders.View with the given head and tail
+// banners (left and right text), numbered from first, in 10 point Arial.
+func HeaderView(alternate bool, first int32, head, tail [2]string) []byte {
+	var content bytes.Buffer
+	content.Write([]byte{0, 0, 1}) // store, view and header versions
+	content.WriteByte(boolByte(alternate))
+	content.WriteByte(1) // number.new
+	LE(&content, first)
+	for _, b := range [][2]string{head, tail} {
+		String16(&content, b[0])
+		String16(&content, b[1])
+		LE(&content, 2*36000) // gap
+	}
+	String16(&content, "Arial")
+	LE(&content, 10*12700) // size
+	LE(&content, 0)        // style
+	LE(&content, 400)      // weight: normal
+	return Store(byte(store.STORE), []string{"StdHeaders.ViewDesc", "Views.ViewDesc", "Stores.StoreDesc"}, content.Bytes(), -1)
+}
+
+func boolByte(b bool) byte {
+	if b {
+		return 1
+	}
+	return 0
+}
+
+// Marker encodes a DevMarkers.StdView for the given error.
+func Marker(mode, err int32, msg string) []byte {
+	var content bytes.Buffer
+	content.Write([]byte{0, 0, 0}) // store, view and marker versions
+	LE(&content, mode)
+	LE(&content, err)
+	String16(&content, msg)
+	path := []string{"DevMarkers.StdViewDesc", "Views.ViewDesc", "Stores.StoreDesc"}
+	return Store(byte(store.STORE), path, content.Bytes(), -1)
+}
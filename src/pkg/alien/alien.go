@@ -3,6 +3,7 @@ package alien
 
 import (
 	"fmt"
+	"io"
 
 	"odcread/pkg/oberon"
 	"odcread/pkg/store"
@@ -11,12 +12,13 @@ import (
 // AlienComponent represents a component of an alien store.
 type AlienComponent interface {
 	String() string
-	Accept(visitor store.Visitor)
 }
 
 // AlienPiece represents raw binary data from an unrecognized part.
 type AlienPiece struct {
-	data []byte
+	data    []byte
+	section *io.SectionReader // Unread data (lazy mode)
+	span    store.Span
 }
 
 // NewAlienPiece creates a new AlienPiece with the given data.
@@ -26,18 +28,47 @@ func NewAlienPiece(data []byte) *AlienPiece {
 	}
 }
 
+// NewDeferredAlienPiece creates an AlienPiece whose data stays in the input
+// until GetData is called.
+func NewDeferredAlienPiece(section *io.SectionReader) *AlienPiece {
+	return &AlienPiece{
+		section: section,
+	}
+}
+
 // String returns a debug representation.
 func (ap *AlienPiece) String() string {
-	return fmt.Sprintf("AlienPiece{%d bytes}", len(ap.data))
+	return fmt.Sprintf("AlienPiece{%d bytes}", ap.Size())
+}
+
+// Size returns the data size in bytes.
+func (ap *AlienPiece) Size() int64 {
+	if ap.section != nil {
+		return ap.section.Size()
+	}
+	return int64(len(ap.data))
+}
+
+// GetSpan returns where the data was read from.
+func (ap *AlienPiece) GetSpan() store.Span {
+	return ap.span
 }
 
-// Accept implements the visitor pattern (no-op for alien pieces).
-func (ap *AlienPiece) Accept(visitor store.Visitor) {
-	// No-op: alien pieces are not processed
+// SetSpan records where the data was read from.
+func (ap *AlienPiece) SetSpan(span store.Span) {
+	ap.span = span
 }
 
-// GetData returns the raw binary data.
+// GetData returns the raw binary data, reading deferred data first.
+// Deferred data that cannot be read is returned as nil.
 func (ap *AlienPiece) GetData() []byte {
+	if ap.section != nil {
+		data := make([]byte, ap.section.Size())
+		if _, err := ap.section.ReadAt(data, 0); err != nil && err != io.EOF {
+			return nil
+		}
+		ap.data, ap.section = data, nil
+	}
 	return ap.data
 }
 
@@ -61,13 +92,6 @@ func (ap *AlienPart) String() string {
 	return "AlienPart{nil}"
 }
 
-// Accept implements the visitor pattern by delegating to the store.
-func (ap *AlienPart) Accept(visitor store.Visitor) {
-	if ap.store != nil {
-		ap.store.Accept(visitor)
-	}
-}
-
 // GetStore returns the wrapped store.
 func (ap *AlienPart) GetStore() store.Store {
 	return ap.store
@@ -109,18 +133,6 @@ func (a *Alien) String() string {
 		a.GetID(), a.path.String(), len(a.comps))
 }
 
-// Accept implements the visitor pattern for Alien.
-func (a *Alien) Accept(visitor store.Visitor) {
-	// Check if this alien has already been visited (prevents cycles from LINK/NEWLINK)
-	if !visitor.ShouldVisit(a) {
-		return
-	}
-
-	for _, comp := range a.comps {
-		comp.Accept(visitor)
-	}
-}
-
 // Internalize is a no-op for Alien - aliens are internalized via internalizeAlien in the reader.
 func (a *Alien) Internalize(reader store.Reader) error {
 	// Aliens don't read their content via the normal internalize path
@@ -137,3 +149,63 @@ func (a *Alien) AddComponent(comp AlienComponent) {
 func (a *Alien) GetComponents() []AlienComponent {
 	return a.comps
 }
+
+// Damaged stands in for a store that could not be read completely.
+// It keeps whatever was read before the damage was detected.
+type Damaged struct {
+	store.BaseStore
+	path    store.TypePath
+	partial store.Store
+	err     error
+}
+
+// NewDamaged creates a placeholder for a damaged store.
+func NewDamaged(id oberon.Integer, path store.TypePath, partial store.Store, err error) *Damaged {
+	return &Damaged{
+		BaseStore: store.NewBaseStore(id),
+		path:      path,
+		partial:   partial,
+		err:       err,
+	}
+}
+
+// GetTypeName returns the type name of the damaged store (from the path).
+func (d *Damaged) GetTypeName() string {
+	if len(d.path) > 0 {
+		return d.path[0]
+	}
+	return "Damaged"
+}
+
+// GetTypePath returns the type path of the damaged store.
+func (d *Damaged) GetTypePath() store.TypePath {
+	return d.path
+}
+
+// String returns a debug representation.
+func (d *Damaged) String() string {
+	return fmt.Sprintf("Damaged{id: %d, path: %s, err: %v}", d.GetID(), d.path.String(), d.err)
+}
+
+// Children returns the partially read store, if any.
+func (d *Damaged) Children() []store.Store {
+	if d.partial == nil {
+		return nil
+	}
+	return []store.Store{d.partial}
+}
+
+// Internalize is a no-op for Damaged - placeholders are created by the reader.
+func (d *Damaged) Internalize(reader store.Reader) error {
+	return nil
+}
+
+// GetPartial returns the partially read store, or nil if nothing could be salvaged.
+func (d *Damaged) GetPartial() store.Store {
+	return d.partial
+}
+
+// Err returns the error that caused the damage.
+func (d *Damaged) Err() error {
+	return d.err
+}
@@ -0,0 +1,165 @@
+// Package bugs locates the parts of a WinBUGS/OpenBUGS analysis in the
+// text of a document: the model block and the data and initial values
+// lists, so that they can be run with JAGS or NIMBLE.
+package bugs
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"odcread/pkg/doodle"
+	"odcread/pkg/extract"
+	"odcread/pkg/store"
+)
+
+// Analysis holds the parts of a BUGS analysis.
+type Analysis struct {
+	Model string   // The model block, "model { ... }", or "" if there is none
+	Data  []string // The data lists, "list(...)"
+	Inits []string // The initial values lists, one per chain
+}
+
+// FromDocument returns the analysis in the main text of root, with
+// collapsed folds expanded (see extract.Extract). If the text has no model
+// block, the model is the code of the first graphical model (doodle) in
+// root.
+func FromDocument(root store.Store) *Analysis {
+	a := Find(extract.Extract(root).Text)
+	if models := doodle.Models(root); a.Model == "" && len(models) > 0 {
+		var sb strings.Builder
+		doodle.WriteBUGS(&sb, models[0])
+		a.Model = strings.TrimSuffix(sb.String(), "\n")
+	}
+	return a
+}
+
+// Find returns the analysis in text. The first model block is the model.
+// A list is data or initial values depending on which of "data" and
+// "init" occurs last in the text since the previous block, e.g. in a
+// heading; if neither does, the first list is data and the others are
+// initial values.
+func Find(text string) *Analysis {
+	a := &Analysis{}
+	prev := 0 // End of the previous block
+	for i := 0; i < len(text); i++ {
+		if !wordStart(text, i) {
+			continue
+		}
+		switch {
+		case a.Model == "" && strings.HasPrefix(text[i:], "model"):
+			if end, ok := block(text, i+len("model"), '{', '}'); ok {
+				a.Model = text[i:end]
+				prev, i = end, end-1
+			}
+		case strings.HasPrefix(text[i:], "list"):
+			// BUGS lists name their entries, unlike "list (...)" in prose
+			if end, ok := block(text, i+len("list"), '(', ')'); ok && strings.Contains(text[i:end], "=") {
+				list := text[i:end]
+				if isInits(text[prev:i], len(a.Data)+len(a.Inits) > 0) {
+					a.Inits = append(a.Inits, list)
+				} else {
+					a.Data = append(a.Data, list)
+				}
+				prev, i = end, end-1
+			}
+		}
+	}
+	return a
+}
+
+// isInits reports whether a list preceded by the text before holds initial
+// values. later is set if it is not the first list.
+func isInits(before string, later bool) bool {
+	before = strings.ToLower(before)
+	init, data := strings.LastIndex(before, "init"), strings.LastIndex(before, "data")
+	if init < 0 && data < 0 {
+		return later
+	}
+	return init > data
+}
+
+// wordStart reports whether a word starts at text[i].
+func wordStart(text string, i int) bool {
+	if i == 0 {
+		return true
+	}
+	c := text[i-1]
+	return !(c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' || c == '_' || c == '.')
+}
+
+// block returns the end of the bracketed block that starts after optional
+// white space at text[i], with open and close as brackets. Brackets in
+// comments, which run from "#" to the end of the line, are ignored.
+func block(text string, i int, open, close byte) (end int, ok bool) {
+	for i < len(text) && strings.IndexByte(" \t\n", text[i]) >= 0 {
+		i++
+	}
+	if i == len(text) || text[i] != open {
+		return 0, false
+	}
+	depth := 0
+	for ; i < len(text); i++ {
+		switch text[i] {
+		case '#':
+			if nl := strings.IndexByte(text[i:], '\n'); nl >= 0 {
+				i += nl
+			} else {
+				i = len(text)
+			}
+		case open:
+			depth++
+		case close:
+			depth--
+			if depth == 0 {
+				return i + 1, true
+			}
+		}
+	}
+	return 0, false
+}
+
+// File is a file of an analysis.
+type File struct {
+	Name    string
+	Content string
+}
+
+// Files returns the files for the analysis: model.bug, data.R (data2.R,
+// ... for further data lists) and inits1.R, inits2.R, ... The lists are
+// written in R dump format (see Dump), so that JAGS can read them.
+func (a *Analysis) Files() []File {
+	var files []File
+	if a.Model != "" {
+		files = append(files, File{"model.bug", a.Model + "\n"})
+	}
+	for i, d := range a.Data {
+		name := "data.R"
+		if i > 0 {
+			name = fmt.Sprintf("data%d.R", i+1)
+		}
+		files = append(files, File{name, Dump(d)})
+	}
+	for i, in := range a.Inits {
+		files = append(files, File{fmt.Sprintf("inits%d.R", i+1), Dump(in)})
+	}
+	return files
+}
+
+// WriteFiles writes the files of the analysis (see Files) to dir and
+// returns their names.
+func (a *Analysis) WriteFiles(dir string) ([]string, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, err
+	}
+	var names []string
+	for _, f := range a.Files() {
+		name := filepath.Join(dir, f.Name)
+		if err := os.WriteFile(name, []byte(f.Content), 0o644); err != nil {
+			return names, err
+		}
+		names = append(names, name)
+	}
+	return names, nil
+}
@@ -0,0 +1,105 @@
+package bugs_test
+
+import (
+	"bytes"
+	"fmt"
+	"testing"
+
+	"odcread/internal/testdoc"
+	"odcread/pkg/bugs"
+	"odcread/pkg/reader"
+	"odcread/pkg/typeregister"
+)
+
+const model = `model {
+	for (i in 1:N) {   # Loop over observations }
+		y[i] ~ dnorm(mu, tau)
+	}
+	mu ~ dnorm(0, 1.0E-6)
+	tau ~ dgamma(0.001, 0.001)
+}`
+
+func TestFromDocument(t *testing.T) {
+	// The data are in a collapsed fold, as WinBUGS examples often have them
+	hidden := testdoc.View{Store: testdoc.Fold(true, "", testdoc.TextModel("list(N = 3, y = c(1.2, 0.8, 1.1))"))}
+	end := testdoc.View{Store: testdoc.Fold(true, "", nil)}
+	doc := testdoc.TextModel(
+		"Normal model\r\r", model, "\r\rData (click to expand) ", hidden, "data", end,
+		"\r\rInits\rlist(mu = 0, tau = 1)\rlist(mu = 10, tau = 0.1)\r\rSee the list (in the manual) of samplers.",
+	)
+	s, err := reader.NewReader(bytes.NewReader(doc)).ReadStore()
+	if err != nil {
+		t.Fatalf("ReadStore failed: %v", err)
+	}
+
+	a := bugs.FromDocument(s)
+	if a.Model != model {
+		t.Errorf("Expected model\n%s\ngot\n%s", model, a.Model)
+	}
+	if len(a.Data) != 1 || a.Data[0] != "list(N = 3, y = c(1.2, 0.8, 1.1))" {
+		t.Errorf("Unexpected data %q", a.Data)
+	}
+	if len(a.Inits) != 2 || a.Inits[1] != "list(mu = 10, tau = 0.1)" {
+		t.Errorf("Unexpected inits %q", a.Inits)
+	}
+
+	var names []string
+	for _, f := range a.Files() {
+		names = append(names, f.Name)
+	}
+	if got := fmt.Sprint(names); got != "[model.bug data.R inits1.R inits2.R]" {
+		t.Errorf("Unexpected files %s", got)
+	}
+}
+
+func TestFind_Position(t *testing.T) {
+	a := bugs.Find("model { x ~ dnorm(0, 1) }\nlist(a = 1)\nlist(x = 0)\n")
+	if len(a.Data) != 1 || len(a.Inits) != 1 || a.Inits[0] != "list(x = 0)" {
+		t.Errorf("Unexpected lists %q, %q", a.Data, a.Inits)
+	}
+}
+
+func TestToR(t *testing.T) {
+	in := "list(n = 2, x = structure(.Data = c(1, 2, 3,\n 4, 5, 6), .Dim = c(2, 3)), v = c(1, 2))"
+	want := "list(n = 2, x = structure(.Data = c(1, 4, 2, 5, 3, 6), .Dim = c(2, 3)), v = c(1, 2))"
+	if got := bugs.ToR(in); got != want {
+		t.Errorf("Expected\n%s\ngot\n%s", want, got)
+	}
+	// One-dimensional and malformed structures are left as they are
+	for _, s := range []string{
+		"list(x = structure(.Data = c(1, 2), .Dim = c(2)))",
+		"list(x = structure(.Data = c(1, 2, 3), .Dim = c(2, 2)))",
+	} {
+		if got := bugs.ToR(s); got != s {
+			t.Errorf("Expected %s unchanged, got %s", s, got)
+		}
+	}
+}
+
+func TestFromDocument_Doodle(t *testing.T) {
+	typeregister.RegisterDoodles()
+	nodes := []testdoc.DoodleNode{{Kind: 0, Name: "x", Density: "dnorm", Params: [][2]string{{"mu", "0"}, {"tau", "1"}}}}
+	s, err := reader.NewReader(bytes.NewReader(testdoc.DoodleView(testdoc.Doodle(nodes, nil, nil)))).ReadStore()
+	if err != nil {
+		t.Fatalf("ReadStore failed: %v", err)
+	}
+	if a := bugs.FromDocument(s); a.Model != "model {\n\tx ~ dnorm(0, 1)\n}" {
+		t.Errorf("Unexpected model %q", a.Model)
+	}
+}
+
+func TestDump(t *testing.T) {
+	in := "list(N = 3, # observations\n y = c(1.2, 0.8,\n 1.1), x = structure(.Data = c(1, 2, 3,\n 4, 5, 6), .Dim = c(2, 3)))"
+	want := "N <- 3\n" +
+		"y <- c(1.2, 0.8, 1.1)\n" +
+		"x <- structure(c(1, 4, 2, 5, 3, 6), .Dim = c(2, 3))\n"
+	if got := bugs.Dump(in); got != want {
+		t.Errorf("Expected\n%s\ngot\n%s", want, got)
+	}
+
+	a := &bugs.Analysis{Data: []string{"list(N = 3)"}, Inits: []string{"list(mu = 0, tau = 1)"}}
+	files := a.Files()
+	if len(files) != 2 || files[0].Content != "N <- 3\n" || files[1].Content != "mu <- 0\ntau <- 1\n" {
+		t.Errorf("Unexpected files %q", files)
+	}
+}
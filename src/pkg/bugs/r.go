@@ -0,0 +1,188 @@
+package bugs
+
+import (
+	"strconv"
+	"strings"
+)
+
+// ToR converts a BUGS list for R. BUGS fills arrays such as
+// structure(.Data = c(1, 2, 3, 4, 5, 6), .Dim = c(2, 3)) row by row, R
+// fills them column by column; ToR reorders the values of such arrays so
+// that R reads the same array. Other text is left as it is.
+func ToR(list string) string {
+	var sb strings.Builder
+	for {
+		i := strings.Index(list, "structure")
+		if i < 0 {
+			break
+		}
+		end, ok := block(list, i+len("structure"), '(', ')')
+		if !ok || !wordStart(list, i) {
+			sb.WriteString(list[:i+len("structure")])
+			list = list[i+len("structure"):]
+			continue
+		}
+		sb.WriteString(list[:i])
+		sb.WriteString(columnMajor(list[i:end]))
+		list = list[end:]
+	}
+	sb.WriteString(list)
+	return sb.String()
+}
+
+// Dump converts a BUGS list such as list(N = 3, y = c(1, 2, 3)) to the
+// R dump format read by JAGS, OpenBUGS scripts and R's source: a line
+// "name <- value" for each element of the list. Arrays are written as
+// structure(c(...), .Dim = c(...)), with the values reordered as by ToR.
+// A list that cannot be parsed is returned converted with ToR.
+func Dump(list string) string {
+	names, values, ok := elements(list)
+	if !ok {
+		return ToR(list) + "\n"
+	}
+	var sb strings.Builder
+	for i, name := range names {
+		sb.WriteString(name + " <- " + dumpValue(values[i]) + "\n")
+	}
+	return sb.String()
+}
+
+// elements returns the names and values of the elements of a list, with
+// comments removed, or false if list is not of the form list(name = value, ...).
+func elements(list string) (names, values []string, ok bool) {
+	var sb strings.Builder
+	for _, line := range strings.Split(list, "\n") {
+		if i := strings.IndexByte(line, '#'); i >= 0 {
+			line = line[:i]
+		}
+		sb.WriteString(line + "\n")
+	}
+	list = strings.TrimSpace(sb.String())
+	if !strings.HasPrefix(list, "list") {
+		return nil, nil, false
+	}
+	if end, ok := block(list, len("list"), '(', ')'); !ok || end != len(list) {
+		return nil, nil, false
+	}
+	inner := strings.TrimSpace(list[len("list"):])
+	inner = inner[1 : len(inner)-1]
+
+	depth, start := 0, 0
+	for i := 0; i <= len(inner); i++ {
+		if i < len(inner) {
+			switch inner[i] {
+			case '(':
+				depth++
+			case ')':
+				depth--
+			}
+			if inner[i] != ',' || depth > 0 {
+				continue
+			}
+		}
+		name, value, found := strings.Cut(inner[start:i], "=")
+		name, value = strings.TrimSpace(name), strings.TrimSpace(value)
+		if !found || !identifier(name) || value == "" {
+			return nil, nil, false
+		}
+		names, values = append(names, name), append(values, value)
+		start = i + 1
+	}
+	return names, values, true
+}
+
+// identifier reports whether s is an R variable name as used in BUGS.
+func identifier(s string) bool {
+	if s == "" || s[0] >= '0' && s[0] <= '9' {
+		return false
+	}
+	for i := 0; i < len(s); i++ {
+		if c := s[i]; !(c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' || c == '_' || c == '.') {
+			return false
+		}
+	}
+	return true
+}
+
+// dumpValue returns a value of a list in R dump format: arrays have their
+// values in column-major order and unnamed, and white space is reduced to
+// single spaces.
+func dumpValue(v string) string {
+	if strings.HasPrefix(v, "structure") {
+		v = columnMajor(v)
+		if _, start, _ := argument(v, ".Data"); start > 0 {
+			v = v[:strings.Index(v, ".Data")] + v[start:]
+		}
+	}
+	return strings.Join(strings.Fields(v), " ")
+}
+
+// columnMajor reorders the .Data of a structure call with two or more
+// dimensions, or returns it unchanged if it cannot be parsed.
+func columnMajor(s string) string {
+	data, dataStart, dataEnd := argument(s, ".Data")
+	dimText, _, _ := argument(s, ".Dim")
+	if data == nil || dimText == nil || len(dimText) < 2 {
+		return s
+	}
+	dims := make([]int, len(dimText))
+	n := 1
+	for i, d := range dimText {
+		v, err := strconv.Atoi(d)
+		if err != nil || v <= 0 {
+			return s
+		}
+		dims[i] = v
+		n *= v
+	}
+	if n != len(data) {
+		return s
+	}
+
+	// The value at row-major position k goes to its column-major position
+	values := make([]string, n)
+	idx := make([]int, len(dims))
+	for k := range data {
+		pos, stride := 0, 1
+		for j := range dims {
+			pos += idx[j] * stride
+			stride *= dims[j]
+		}
+		values[pos] = data[k]
+		for j := len(dims) - 1; j >= 0; j-- {
+			if idx[j]++; idx[j] < dims[j] {
+				break
+			}
+			idx[j] = 0
+		}
+	}
+	return s[:dataStart] + "c(" + strings.Join(values, ", ") + ")" + s[dataEnd:]
+}
+
+// argument returns the values of the "name = c(...)" argument in s and
+// the range of "c(...)" in s, or nil if there is no such argument.
+func argument(s, name string) (values []string, start, end int) {
+	i := strings.Index(s, name)
+	if i < 0 {
+		return nil, 0, 0
+	}
+	rest := strings.TrimLeft(s[i+len(name):], " \t\n")
+	if !strings.HasPrefix(rest, "=") {
+		return nil, 0, 0
+	}
+	rest = strings.TrimLeft(rest[1:], " \t\n")
+	if !strings.HasPrefix(rest, "c") {
+		return nil, 0, 0
+	}
+	start = len(s) - len(rest)
+	end, ok := block(s, start+1, '(', ')')
+	if !ok {
+		return nil, 0, 0
+	}
+	inner := strings.TrimSpace(s[start+1 : end])
+	inner = strings.TrimSuffix(strings.TrimPrefix(inner, "("), ")")
+	for _, v := range strings.Split(inner, ",") {
+		values = append(values, strings.TrimSpace(v))
+	}
+	return values, start, end
+}
@@ -0,0 +1,60 @@
+// Package clock provides clock views, which show the current time.
+package clock
+
+import (
+	"fmt"
+
+	"odcread/pkg/fold"
+	"odcread/pkg/oberon"
+	"odcread/pkg/store"
+)
+
+const TypeNameClock = "StdClocks.StdView^"
+
+// Clock is a clock view (StdClocks.StdView). It shows the time it is
+// displayed at, so no time is stored with it.
+type Clock struct {
+	fold.View
+	format byte
+}
+
+// NewClock creates a new Clock instance.
+func NewClock(id oberon.Integer) *Clock {
+	return &Clock{
+		View: *fold.NewView(id),
+	}
+}
+
+// GetTypeName returns the type name for Clock.
+func (c *Clock) GetTypeName() string {
+	return TypeNameClock
+}
+
+// Internalize reads Clock data from the reader.
+// Format (StdClocks.StdView.Internalize):
+//
+//	version (0)
+//	format (byte)
+func (c *Clock) Internalize(reader store.Reader) error {
+	if err := c.View.Internalize(reader); err != nil {
+		return err
+	}
+	if _, err := reader.ReadVersion(0, 0); err != nil {
+		return err
+	}
+	var err error
+	if c.format, err = reader.ReadByte(); err != nil {
+		return fmt.Errorf("failed to read clock format: %w", err)
+	}
+	return nil
+}
+
+// String returns a string representation of the Clock.
+func (c *Clock) String() string {
+	return fmt.Sprintf("Clock{id: %d, format: %d}", c.GetID(), c.format)
+}
+
+// InlineText returns a placeholder for the clock, "[clock]".
+func (c *Clock) InlineText() string {
+	return "[clock]"
+}
@@ -0,0 +1,44 @@
+package clock_test
+
+import (
+	"strings"
+	"testing"
+
+	"odcread/internal/odc"
+	"odcread/internal/testdoc"
+	"odcread/internal/testdoc/load"
+	"odcread/pkg/clock"
+	"odcread/pkg/extract"
+	_ "odcread/pkg/typeregister" // Import for side-effect (type registration)
+)
+
+func TestClock(t *testing.T) {
+	doc := testdoc.TextModel("Now: ", testdoc.View{Store: testdoc.Clock(2)})
+	s := load.Store(t, doc)
+
+	c, ok := load.View(t, s, 1).(*clock.Clock)
+	if !ok {
+		t.Fatalf("Expected a clock, got %v", s)
+	}
+	if c.String() != "Clock{id: 0, format: 2}" {
+		t.Errorf("Unexpected clock %s", c)
+	}
+
+	if got := extract.Extract(s).Text; got != "Now: [clock]" {
+		t.Errorf("Expected %q, got %q", "Now: [clock]", got)
+	}
+	var sb strings.Builder
+	odc.NewMyVisitor(&sb).Visit(s)
+	if !strings.Contains(sb.String(), "Now: [clock]") {
+		t.Errorf("Expected the text output to show the clock, got %q", sb.String())
+	}
+}
+
+// TestClock_Corpus checks the clocks of the documents written by BlackBox.
+func TestClock_Corpus(t *testing.T) {
+	for _, s := range load.Corpus(t, clock.TypeNameClock) {
+		if _, ok := s.(*clock.Clock); !ok {
+			t.Errorf("Expected a clock, got %v", s)
+		}
+	}
+}
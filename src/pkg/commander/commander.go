@@ -0,0 +1,87 @@
+// Package commander provides commanders (DevCommanders), the arrows in
+// texts that execute the command following them when clicked, and the
+// end views that terminate such commands.
+package commander
+
+import (
+	"fmt"
+
+	"odcread/pkg/fold"
+	"odcread/pkg/oberon"
+	"odcread/pkg/store"
+)
+
+const (
+	TypeNameStdView    = "DevCommanders.StdView^"
+	TypeNameStdEndView = "DevCommanders.StdEndView^"
+)
+
+// StdView is a commander (DevCommanders.StdView). The command is the text
+// that follows it up to an end view, the next commander or the end of
+// the paragraph.
+type StdView struct {
+	fold.View
+}
+
+// NewStdView creates a new StdView instance.
+func NewStdView(id oberon.Integer) *StdView {
+	return &StdView{
+		View: *fold.NewView(id),
+	}
+}
+
+// GetTypeName returns the type name for StdView.
+func (v *StdView) GetTypeName() string {
+	return TypeNameStdView
+}
+
+// Internalize reads StdView data from the reader.
+// Format (DevCommanders.StdView.Internalize):
+//
+//	version (0)
+func (v *StdView) Internalize(reader store.Reader) error {
+	if err := v.View.Internalize(reader); err != nil {
+		return err
+	}
+	_, err := reader.ReadVersion(0, 0)
+	return err
+}
+
+// String returns a string representation of the StdView.
+func (v *StdView) String() string {
+	return fmt.Sprintf("Commander{id: %d}", v.GetID())
+}
+
+// StdEndView terminates the command of a commander (DevCommanders.StdEndView).
+type StdEndView struct {
+	fold.View
+}
+
+// NewStdEndView creates a new StdEndView instance.
+func NewStdEndView(id oberon.Integer) *StdEndView {
+	return &StdEndView{
+		View: *fold.NewView(id),
+	}
+}
+
+// GetTypeName returns the type name for StdEndView.
+func (v *StdEndView) GetTypeName() string {
+	return TypeNameStdEndView
+}
+
+// Internalize reads StdEndView data from the reader.
+// Format (DevCommanders.StdEndView.Internalize):
+//
+//	version (0)
+func (v *StdEndView) Internalize(reader store.Reader) error {
+	if err := v.View.Internalize(reader); err != nil {
+		return err
+	}
+	_, err := reader.ReadVersion(0, 0)
+	return err
+}
+
+// String returns a string representation of the StdEndView.
+func (v *StdEndView) String() string {
+	return fmt.Sprintf("CommanderEnd{id: %d}", v.GetID())
+}
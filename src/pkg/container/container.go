@@ -0,0 +1,92 @@
+// Package container provides the base type of views that show a model.
+package container
+
+import (
+	"fmt"
+
+	"odcread/pkg/alien"
+	"odcread/pkg/fold"
+	"odcread/pkg/oberon"
+	"odcread/pkg/store"
+)
+
+const TypeNameView = "Containers.View^"
+
+// View is a view with a model and an optional controller (Containers.View).
+type View struct {
+	fold.View
+	model      store.Store
+	controller store.Store
+}
+
+// NewView creates a new View instance.
+func NewView(id oberon.Integer) *View {
+	return &View{
+		View: *fold.NewView(id),
+	}
+}
+
+// GetTypeName returns the type name for View.
+func (v *View) GetTypeName() string {
+	return TypeNameView
+}
+
+// Internalize reads the model and the controller.
+// A view whose model is an alien is turned into an alien itself.
+func (v *View) Internalize(reader store.Reader) error {
+	if err := v.View.Internalize(reader); err != nil {
+		return err
+	}
+	if _, err := reader.ReadVersion(0, 0); err != nil {
+		return err
+	}
+
+	model, err := reader.ReadStore()
+	if err != nil {
+		return fmt.Errorf("failed to read model: %w", err)
+	}
+	switch model.(type) {
+	case nil:
+		return fmt.Errorf("view has no model")
+	case *alien.Alien:
+		return reader.TurnIntoAlien(store.AlienComponent)
+	}
+	v.model = model
+
+	// The controller is nil or a store, possibly an alien
+	if v.controller, err = reader.ReadStore(); err != nil {
+		return fmt.Errorf("failed to read controller: %w", err)
+	}
+	return nil
+}
+
+// String returns a string representation of the View.
+func (v *View) String() string {
+	return fmt.Sprintf("ContainerView{id: %d}", v.GetID())
+}
+
+// GetModel returns the view's model.
+func (v *View) GetModel() store.Store {
+	return v.model
+}
+
+// GetController returns the view's controller, or nil.
+func (v *View) GetController() store.Store {
+	return v.controller
+}
+
+// Children returns the model and the controller.
+func (v *View) Children() []store.Store {
+	return nonNil(v.model, v.controller)
+}
+
+// nonNil returns the stores that are not nil.
+func nonNil(stores ...store.Store) []store.Store {
+	var list []store.Store
+	for _, s := range stores {
+		if s != nil {
+			list = append(list, s)
+		}
+	}
+	return list
+}
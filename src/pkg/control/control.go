@@ -0,0 +1,188 @@
+// Package control provides the standard dialog controls (Controls), such
+// as push buttons, fields and check boxes.
+package control
+
+import (
+	"fmt"
+	"strings"
+
+	"odcread/pkg/fold"
+	"odcread/pkg/font"
+	"odcread/pkg/oberon"
+	"odcread/pkg/store"
+)
+
+// Type names of the standard controls.
+const (
+	TypeNamePushButton   = "Controls.PushButton^"
+	TypeNameCheckBox     = "Controls.CheckBox^"
+	TypeNameRadioButton  = "Controls.RadioButton^"
+	TypeNameField        = "Controls.Field^"
+	TypeNameUpDownField  = "Controls.UpDownField^"
+	TypeNameDateField    = "Controls.DateField^"
+	TypeNameTimeField    = "Controls.TimeField^"
+	TypeNameColorField   = "Controls.ColorField^"
+	TypeNameListBox      = "Controls.ListBox^"
+	TypeNameSelectionBox = "Controls.SelectionBox^"
+	TypeNameComboBox     = "Controls.ComboBox^"
+	TypeNameCaption      = "Controls.Caption^"
+	TypeNameGroup        = "Controls.Group^"
+)
+
+// TypeNames lists the type names of the standard controls whose own data
+// (Internalize2) is decoded. Others, such as Controls.TreeControl, are
+// read as aliens.
+var TypeNames = []string{
+	TypeNamePushButton, TypeNameCheckBox, TypeNameRadioButton,
+	TypeNameField, TypeNameUpDownField, TypeNameDateField, TypeNameTimeField, TypeNameColorField,
+	TypeNameListBox, TypeNameSelectionBox, TypeNameComboBox,
+	TypeNameCaption, TypeNameGroup,
+}
+
+// Control is a standard control (Controls.Control). Its type name is the
+// one it was created with, e.g. TypeNamePushButton.
+type Control struct {
+	fold.View
+	typeName string
+	link     string // Designator of the bound variable or procedure
+	label    string
+	guard    string // Procedure that enables or disables the control
+	notifier string // Procedure called when the bound value changes
+	level    oberon.Integer
+	opt      [5]bool    // Control-specific options, e.g. "default" for push buttons
+	font     *font.Font // Custom font, or nil for the default font
+
+	min, max, inc oberon.Integer // UpDownField: range and step
+}
+
+// New creates a new Control of the given type.
+func New(id oberon.Integer, typeName string) *Control {
+	return &Control{
+		View:     *fold.NewView(id),
+		typeName: typeName,
+	}
+}
+
+// GetTypeName returns the type name the control was created with.
+func (c *Control) GetTypeName() string {
+	return c.typeName
+}
+
+// Kind returns the control type without its module, e.g. "PushButton".
+func (c *Control) Kind() string {
+	return strings.TrimSuffix(strings.TrimPrefix(c.typeName, "Controls."), "^")
+}
+
+// Internalize reads Control data from the reader.
+// Format (Controls.Control.Internalize):
+//
+//	version (0..2)
+//	link, label, guard, notifier (sstring in version 0, string otherwise)
+//	level (int)
+//	customFont (bool), opt[0..4] (bool)
+//	if customFont: font (see font.Read)
+//	version (0) of the control type, then its data (Internalize2):
+//	  UpDownField: min, max, inc (int)
+//	  other types: none
+func (c *Control) Internalize(reader store.Reader) error {
+	if err := c.View.Internalize(reader); err != nil {
+		return err
+	}
+	version, err := reader.ReadVersion(0, 2)
+	if err != nil {
+		return err
+	}
+
+	for _, p := range []*string{&c.link, &c.label, &c.guard, &c.notifier} {
+		if version == 0 {
+			*p, err = reader.ReadXString()
+		} else {
+			*p, err = reader.ReadString()
+		}
+		if err != nil {
+			return fmt.Errorf("failed to read control properties: %w", err)
+		}
+	}
+	if c.level, err = reader.ReadInt(); err != nil {
+		return fmt.Errorf("failed to read control level: %w", err)
+	}
+	customFont, err := reader.ReadBool()
+	if err != nil {
+		return fmt.Errorf("failed to read control font flag: %w", err)
+	}
+	for i := range c.opt {
+		if c.opt[i], err = reader.ReadBool(); err != nil {
+			return fmt.Errorf("failed to read control options: %w", err)
+		}
+	}
+	if customFont {
+		f, err := font.Read(reader)
+		if err != nil {
+			return fmt.Errorf("failed to read control font: %w", err)
+		}
+		c.font = &f
+	}
+	return c.internalize2(reader)
+}
+
+// internalize2 reads the data of the control type.
+func (c *Control) internalize2(reader store.Reader) error {
+	if _, err := reader.ReadVersion(0, 0); err != nil {
+		return err
+	}
+	if c.typeName == TypeNameUpDownField {
+		var err error
+		for _, p := range []*oberon.Integer{&c.min, &c.max, &c.inc} {
+			if *p, err = reader.ReadInt(); err != nil {
+				return fmt.Errorf("failed to read up-down field range: %w", err)
+			}
+		}
+	}
+	return nil
+}
+
+// String returns a string representation of the Control.
+func (c *Control) String() string {
+	return fmt.Sprintf("Control{id: %d, kind: %s, label: %q, link: %q}", c.GetID(), c.Kind(), c.label, c.link)
+}
+
+// GetLink returns the designator of the bound variable or procedure.
+func (c *Control) GetLink() string {
+	return c.link
+}
+
+// GetLabel returns the control's label. An ampersand marks the following
+// character as the keyboard shortcut.
+func (c *Control) GetLabel() string {
+	return c.label
+}
+
+// GetGuard returns the guard procedure, or "".
+func (c *Control) GetGuard() string {
+	return c.guard
+}
+
+// GetNotifier returns the notifier procedure, or "".
+func (c *Control) GetNotifier() string {
+	return c.notifier
+}
+
+// GetLevel returns the control's level, which groups radio buttons.
+func (c *Control) GetLevel() oberon.Integer {
+	return c.level
+}
+
+// Option reports whether the control-specific option i (0..4) is set.
+func (c *Control) Option(i int) bool {
+	return i >= 0 && i < len(c.opt) && c.opt[i]
+}
+
+// GetFont returns the custom font, or nil for the default font.
+func (c *Control) GetFont() *font.Font {
+	return c.font
+}
+
+// GetRange returns the range and step of an UpDownField.
+func (c *Control) GetRange() (min, max, inc oberon.Integer) {
+	return c.min, c.max, c.inc
+}
@@ -0,0 +1,66 @@
+package control_test
+
+import (
+	"bytes"
+	"testing"
+
+	"odcread/internal/testdoc"
+	"odcread/internal/testdoc/load"
+	"odcread/pkg/alien"
+	"odcread/pkg/control"
+	"odcread/pkg/form"
+	"odcread/pkg/reader"
+	_ "odcread/pkg/typeregister" // Import for side-effect (type registration)
+)
+
+func TestUpDownField(t *testing.T) {
+	doc := testdoc.Control("UpDownField", "Dialog.count", "", "", "", 1, 99, 2)
+	r := reader.NewReader(bytes.NewReader(doc))
+	s, err := r.ReadStore()
+	if err != nil {
+		t.Fatalf("ReadStore failed: %v", err)
+	}
+	c, ok := s.(*control.Control)
+	if !ok {
+		t.Fatalf("Expected a control, got %v", s)
+	}
+	if c.Kind() != "UpDownField" || c.GetLink() != "Dialog.count" {
+		t.Errorf("Unexpected control %s", c)
+	}
+	if min, max, inc := c.GetRange(); min != 1 || max != 99 || inc != 2 {
+		t.Errorf("Expected range 1..99 step 2, got %d..%d step %d", min, max, inc)
+	}
+	if len(r.Damages()) != 0 {
+		t.Errorf("Unexpected damages %v", r.Damages())
+	}
+}
+
+func TestUndecodedControl(t *testing.T) {
+	// Tree controls are not decoded; they are aliens and keep their type name
+	doc := testdoc.Form(testdoc.FormItem{
+		View:   testdoc.Control("TreeControl", "Dialog.tree", "", "", ""),
+		Bounds: [4]int32{0, 0, 100, 100},
+	})
+	s := load.Store(t, doc)
+	m := s.(*form.StdModel)
+	if _, ok := m.GetItems()[0].View.(*alien.Alien); !ok {
+		t.Fatalf("Expected an alien, got %v", m.GetItems()[0].View)
+	}
+	if got := form.Dialogs(s)[0].Controls[0].Type; got != "Controls.TreeControl" {
+		t.Errorf("Expected type %q, got %q", "Controls.TreeControl", got)
+	}
+}
+
+// TestControl_Corpus checks the controls of the documents written by BlackBox.
+func TestControl_Corpus(t *testing.T) {
+	for _, name := range control.TypeNames {
+		name := name
+		t.Run(name, func(t *testing.T) {
+			for _, s := range load.Corpus(t, name) {
+				if c := s.(*control.Control); c.GetTypeName() != name {
+					t.Errorf("Expected a %s, got %s", name, c)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,223 @@
+// Package document provides the standard document, the root view of
+// BlackBox document files, with its page setup.
+package document
+
+import (
+	"fmt"
+
+	"odcread/pkg/container"
+	"odcread/pkg/oberon"
+	"odcread/pkg/store"
+)
+
+const (
+	TypeNameModel       = "Documents.Model^"
+	TypeNameStdDocument = "Documents.StdDocument^"
+)
+
+// Universal units per millimetre (Ports.mm).
+const mm = 36000
+
+// Rect is a rectangle in universal units (1/36000 mm).
+type Rect struct {
+	Left   oberon.Integer `json:"left"`
+	Top    oberon.Integer `json:"top"`
+	Right  oberon.Integer `json:"right"`
+	Bottom oberon.Integer `json:"bottom"`
+}
+
+// Width returns the width of the rectangle.
+func (r Rect) Width() oberon.Integer {
+	return r.Right - r.Left
+}
+
+// Height returns the height of the rectangle.
+func (r Rect) Height() oberon.Integer {
+	return r.Bottom - r.Top
+}
+
+// String returns the rectangle's size in millimetres.
+func (r Rect) String() string {
+	return fmt.Sprintf("%s x %s mm", MM(r.Width()), MM(r.Height()))
+}
+
+// MM formats a length in universal units as millimetres.
+func MM(x oberon.Integer) string {
+	return fmt.Sprintf("%.1f", float64(x)/mm)
+}
+
+// Page is the page setup of a document.
+type Page struct {
+	Width, Height oberon.Integer
+	Area          Rect // Printable area, within the page
+	Decorate      bool // Print a header with the page number and document name
+}
+
+// Margins returns the distances of the printable area from the page edges.
+func (p Page) Margins() (left, top, right, bottom oberon.Integer) {
+	return p.Area.Left, p.Area.Top, p.Width - p.Area.Right, p.Height - p.Area.Bottom
+}
+
+// String returns a description such as "210.0 x 297.0 mm, margins
+// 20.0/20.0/20.0/20.0 mm".
+func (p Page) String() string {
+	l, t, r, b := p.Margins()
+	s := fmt.Sprintf("%s x %s mm, margins %s/%s/%s/%s mm (left/top/right/bottom)",
+		MM(p.Width), MM(p.Height), MM(l), MM(t), MM(r), MM(b))
+	if p.Decorate {
+		s += ", decorated"
+	}
+	return s
+}
+
+// Model is the model of a document (Documents.Model). It holds the
+// document's view and the view's bounds.
+type Model struct {
+	store.ContainerModel
+	view   store.Store
+	bounds Rect
+}
+
+// NewModel creates a new Model instance.
+func NewModel(id oberon.Integer) *Model {
+	return &Model{
+		ContainerModel: *store.NewContainerModel(id),
+	}
+}
+
+// GetTypeName returns the type name for Model.
+func (m *Model) GetTypeName() string {
+	return TypeNameModel
+}
+
+// Internalize reads Model data from the reader.
+// Format (Documents.Model.Internalize):
+//
+//	version (0)
+//	view (store)
+//	l, t, r, b (int): bounds of the view
+func (m *Model) Internalize(reader store.Reader) error {
+	if err := m.ContainerModel.Internalize(reader); err != nil {
+		return err
+	}
+	if _, err := reader.ReadVersion(0, 0); err != nil {
+		return err
+	}
+
+	var err error
+	if m.view, err = reader.ReadStore(); err != nil {
+		return fmt.Errorf("failed to read view: %w", err)
+	}
+	return readRect(reader, &m.bounds)
+}
+
+// String returns a string representation of the Model.
+func (m *Model) String() string {
+	return fmt.Sprintf("DocumentModel{id: %d, bounds: %s}", m.GetID(), m.bounds)
+}
+
+// GetView returns the document's view.
+func (m *Model) GetView() store.Store {
+	return m.view
+}
+
+// GetBounds returns the bounds of the view.
+func (m *Model) GetBounds() Rect {
+	return m.bounds
+}
+
+// Children returns the view.
+func (m *Model) Children() []store.Store {
+	if m.view == nil {
+		return nil
+	}
+	return []store.Store{m.view}
+}
+
+// StdDocument is the standard document (Documents.StdDocument).
+type StdDocument struct {
+	container.View
+	page Page
+}
+
+// NewStdDocument creates a new StdDocument instance.
+func NewStdDocument(id oberon.Integer) *StdDocument {
+	return &StdDocument{
+		View: *container.NewView(id),
+	}
+}
+
+// GetTypeName returns the type name for StdDocument.
+func (d *StdDocument) GetTypeName() string {
+	return TypeNameStdDocument
+}
+
+// Internalize reads StdDocument data from the reader.
+// Format (Documents.StdDocument.Internalize2):
+//
+//	version (0)
+//	w, h (int): page size
+//	l, t, r, b (int): printable area
+//	decorate (bool)
+func (d *StdDocument) Internalize(reader store.Reader) error {
+	if err := d.View.Internalize(reader); err != nil {
+		return err
+	}
+	if _, err := reader.ReadVersion(0, 0); err != nil {
+		return err
+	}
+
+	var err error
+	if d.page.Width, err = reader.ReadInt(); err != nil {
+		return fmt.Errorf("failed to read page width: %w", err)
+	}
+	if d.page.Height, err = reader.ReadInt(); err != nil {
+		return fmt.Errorf("failed to read page height: %w", err)
+	}
+	if err := readRect(reader, &d.page.Area); err != nil {
+		return err
+	}
+	if d.page.Decorate, err = reader.ReadBool(); err != nil {
+		return fmt.Errorf("failed to read decorate flag: %w", err)
+	}
+	return nil
+}
+
+// String returns a string representation of the StdDocument.
+func (d *StdDocument) String() string {
+	return fmt.Sprintf("StdDocument{id: %d, page: %s}", d.GetID(), d.page)
+}
+
+// GetPage returns the page setup.
+func (d *StdDocument) GetPage() Page {
+	return d.page
+}
+
+// GetView returns the view shown by the document, or nil.
+func (d *StdDocument) GetView() store.Store {
+	if m, ok := d.GetModel().(*Model); ok {
+		return m.GetView()
+	}
+	return nil
+}
+
+// GetBounds returns the bounds of the document's view, which determine
+// the size of the window the document is opened in.
+func (d *StdDocument) GetBounds() Rect {
+	if m, ok := d.GetModel().(*Model); ok {
+		return m.GetBounds()
+	}
+	return Rect{}
+}
+
+// readRect reads the four coordinates of r.
+func readRect(reader store.Reader, r *Rect) error {
+	for _, p := range []*oberon.Integer{&r.Left, &r.Top, &r.Right, &r.Bottom} {
+		v, err := reader.ReadInt()
+		if err != nil {
+			return fmt.Errorf("failed to read rectangle: %w", err)
+		}
+		*p = v
+	}
+	return nil
+}
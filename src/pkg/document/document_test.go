@@ -0,0 +1,47 @@
+package document_test
+
+import (
+	"testing"
+
+	"odcread/internal/testdoc"
+	"odcread/internal/testdoc/load"
+	"odcread/pkg/document"
+	"odcread/pkg/textview"
+	_ "odcread/pkg/typeregister" // Import for side-effect (type registration)
+)
+
+func TestStdDocument(t *testing.T) {
+	const mm = 36000
+	view := testdoc.StdView(testdoc.TextModel("Hello"), 0, false)
+	page := [10]int32{210 * mm, 297 * mm, 20 * mm, 25 * mm, 190 * mm, 277 * mm, 0, 0, 150 * mm, 100 * mm}
+	doc := testdoc.Document(view, page, true)
+
+	s := load.Store(t, doc)
+	d, ok := s.(*document.StdDocument)
+	if !ok {
+		t.Fatalf("Expected *StdDocument, got %s", s)
+	}
+
+	want := "210.0 x 297.0 mm, margins 20.0/25.0/20.0/20.0 mm (left/top/right/bottom), decorated"
+	if got := d.GetPage().String(); got != want {
+		t.Errorf("Expected page %q, got %q", want, got)
+	}
+	if got := d.GetBounds().String(); got != "150.0 x 100.0 mm" {
+		t.Errorf("Unexpected bounds %q", got)
+	}
+	if _, ok := d.GetView().(*textview.StdView); !ok {
+		t.Errorf("Expected a text view, got %v", d.GetView())
+	}
+	if textview.MainText(d) == nil {
+		t.Error("Expected the document's main text")
+	}
+}
+
+// TestStdDocument_Corpus checks the documents written by BlackBox.
+func TestStdDocument_Corpus(t *testing.T) {
+	for _, s := range load.Corpus(t, document.TypeNameStdDocument) {
+		if d := s.(*document.StdDocument); d.GetView() == nil {
+			t.Errorf("Expected the document's view, got %s", d)
+		}
+	}
+}
@@ -0,0 +1,253 @@
+// Package dom builds a document object model over parsed stores, in which
+// every node knows its parent, its index in the parent and its position in
+// the enclosing text.
+package dom
+
+import (
+	"fmt"
+	"strings"
+
+	"odcread/pkg/alien"
+	"odcread/pkg/encoding"
+	"odcread/pkg/fold"
+	"odcread/pkg/store"
+	"odcread/pkg/textmodel"
+	"odcread/pkg/visitor"
+)
+
+// Kind identifies the type of a node.
+type Kind int
+
+const (
+	Store      Kind = iota // A store without special handling
+	TextModel              // A text model; its children are its pieces
+	ShortPiece             // Text with 8-bit characters
+	LongPiece              // Text with 16-bit characters
+	ViewPiece              // A view embedded in text; its child is the view
+	Fold                   // A fold; its child is the hidden part
+	Alien                  // A store of an unregistered type; its children are its components
+	AlienPiece             // Raw data inside an alien
+	AlienPart              // A store inside an alien; its child is the store
+)
+
+// String returns the name of the node kind.
+func (k Kind) String() string {
+	switch k {
+	case Store:
+		return "Store"
+	case TextModel:
+		return "TextModel"
+	case ShortPiece:
+		return "ShortPiece"
+	case LongPiece:
+		return "LongPiece"
+	case ViewPiece:
+		return "ViewPiece"
+	case Fold:
+		return "Fold"
+	case Alien:
+		return "Alien"
+	case AlienPiece:
+		return "AlienPiece"
+	case AlienPart:
+		return "AlienPart"
+	default:
+		return fmt.Sprintf("Kind(%d)", int(k))
+	}
+}
+
+// Node is an element of the document.
+type Node struct {
+	Kind      Kind
+	Store     store.Store          // Store, TextModel, Fold and Alien nodes
+	Piece     textmodel.TextPiece  // ShortPiece, LongPiece and ViewPiece nodes
+	Component alien.AlienComponent // AlienPiece and AlienPart nodes
+
+	Parent   *Node
+	Index    int // Index in Parent.Children
+	Children []*Node
+
+	// Pos is the character position of the node in its enclosing text
+	// (see Enclosing), or -1 if it is not inside any text. Nodes below a
+	// view piece share the view piece's position.
+	Pos int
+	// Len is the number of characters of a piece or text model.
+	Len int
+}
+
+// Build returns the tree of nodes for the stores rooted at root.
+// A store that is reachable more than once through links appears only
+// where it is first reached.
+func Build(root store.Store) *Node {
+	b := &builder{}
+	visitor.Visit(root, b)
+	return b.root
+}
+
+// Enclosing returns the nearest text model containing the node, or nil.
+func (n *Node) Enclosing() *Node {
+	for p := n.Parent; p != nil; p = p.Parent {
+		if p.Kind == TextModel {
+			return p
+		}
+	}
+	return nil
+}
+
+// Ancestors returns the chain of nodes from the root to the node's parent.
+func (n *Node) Ancestors() []*Node {
+	var chain []*Node
+	for p := n.Parent; p != nil; p = p.Parent {
+		chain = append(chain, p)
+	}
+	for i, j := 0, len(chain)-1; i < j; i, j = i+1, j-1 {
+		chain[i], chain[j] = chain[j], chain[i]
+	}
+	return chain
+}
+
+// Walk calls fn for the node and its descendants in document order.
+// Children of a node for which fn returns false are skipped.
+func (n *Node) Walk(fn func(*Node) bool) {
+	if !fn(n) {
+		return
+	}
+	for _, c := range n.Children {
+		c.Walk(fn)
+	}
+}
+
+// Find returns the node and descendants for which match returns true, in document order.
+func (n *Node) Find(match func(*Node) bool) []*Node {
+	var found []*Node
+	n.Walk(func(c *Node) bool {
+		if match(c) {
+			found = append(found, c)
+		}
+		return true
+	})
+	return found
+}
+
+// Text returns the text of a piece or text model, excluding embedded views.
+func (n *Node) Text() string {
+	switch p := n.Piece.(type) {
+	case *textmodel.ShortPiece:
+		str, _ := encoding.ConvertLatin1(p.GetBuffer())
+		return str
+	case *textmodel.LongPiece:
+		str, _ := encoding.ConvertUCS2(p.GetBuffer())
+		return str
+	}
+	if n.Kind != TextModel {
+		return ""
+	}
+	var sb strings.Builder
+	for _, c := range n.Children {
+		sb.WriteString(c.Text())
+	}
+	return sb.String()
+}
+
+// String returns a description such as `Fold "label" at 1234`.
+func (n *Node) String() string {
+	var sb strings.Builder
+	sb.WriteString(n.Kind.String())
+	switch {
+	case n.Kind == Fold:
+		fmt.Fprintf(&sb, " %q", n.Store.(*fold.Fold).GetLabel())
+	case n.Store != nil:
+		sb.WriteString(" " + n.Store.GetTypeName())
+	}
+	if n.Pos >= 0 {
+		fmt.Fprintf(&sb, " at %d", n.Pos)
+	}
+	return sb.String()
+}
+
+// builder creates the nodes while the stores are visited.
+type builder struct {
+	visitor.Base
+	root  *Node
+	stack []*Node
+}
+
+// add attaches a new node to the current node and computes its position.
+func (b *builder) add(n *Node) *Node {
+	n.Pos = -1
+	if len(b.stack) == 0 {
+		b.root = n
+		return n
+	}
+
+	parent := b.stack[len(b.stack)-1]
+	n.Parent, n.Index = parent, len(parent.Children)
+	parent.Children = append(parent.Children, n)
+	if parent.Kind == TextModel {
+		n.Pos = parent.Len
+		parent.Len += n.Len
+	} else {
+		n.Pos = parent.Pos
+	}
+	return n
+}
+
+func (b *builder) push(n *Node) visitor.Action {
+	b.stack = append(b.stack, b.add(n))
+	return visitor.Continue
+}
+
+func (b *builder) pop() {
+	b.stack = b.stack[:len(b.stack)-1]
+}
+
+func (b *builder) VisitTextModel(m *textmodel.StdTextModel) visitor.Action {
+	return b.push(&Node{Kind: TextModel, Store: m})
+}
+
+func (b *builder) LeaveTextModel(m *textmodel.StdTextModel) { b.pop() }
+
+func (b *builder) VisitShortPiece(p *textmodel.ShortPiece) visitor.Action {
+	b.add(&Node{Kind: ShortPiece, Piece: p, Len: int(p.Size())})
+	return visitor.Continue
+}
+
+func (b *builder) VisitLongPiece(p *textmodel.LongPiece) visitor.Action {
+	b.add(&Node{Kind: LongPiece, Piece: p, Len: int(p.Size())})
+	return visitor.Continue
+}
+
+func (b *builder) VisitViewPiece(p *textmodel.ViewPiece) visitor.Action {
+	return b.push(&Node{Kind: ViewPiece, Piece: p, Len: 1})
+}
+
+func (b *builder) LeaveViewPiece(p *textmodel.ViewPiece) { b.pop() }
+
+func (b *builder) VisitFold(f *fold.Fold) visitor.Action {
+	return b.push(&Node{Kind: Fold, Store: f})
+}
+
+func (b *builder) LeaveFold(f *fold.Fold) { b.pop() }
+
+func (b *builder) VisitAlien(a *alien.Alien) visitor.Action {
+	return b.push(&Node{Kind: Alien, Store: a})
+}
+
+func (b *builder) LeaveAlien(a *alien.Alien) { b.pop() }
+
+func (b *builder) VisitAlienPiece(p *alien.AlienPiece) visitor.Action {
+	b.add(&Node{Kind: AlienPiece, Component: p})
+	return visitor.Continue
+}
+
+func (b *builder) VisitAlienPart(p *alien.AlienPart) visitor.Action {
+	return b.push(&Node{Kind: AlienPart, Component: p})
+}
+
+func (b *builder) LeaveAlienPart(p *alien.AlienPart) { b.pop() }
+
+func (b *builder) VisitStore(s store.Store) visitor.Action {
+	return b.push(&Node{Kind: Store, Store: s})
+}
+
+func (b *builder) LeaveStore(s store.Store) { b.pop() }
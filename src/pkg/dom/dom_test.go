@@ -0,0 +1,45 @@
+package dom
+
+import (
+	"bytes"
+	"testing"
+
+	"odcread/internal/testdoc"
+	"odcread/pkg/reader"
+	_ "odcread/pkg/typeregister" // Import for side-effect (type registration)
+)
+
+func TestBuild(t *testing.T) {
+	hidden := testdoc.TextModel("ab", testdoc.View{Store: testdoc.Fold(false, "inner", testdoc.TextModel("x"))})
+	doc := testdoc.TextModel("Hello ", testdoc.View{Store: testdoc.Fold(true, "outer", hidden)}, "world")
+
+	s, err := reader.NewReader(bytes.NewReader(doc)).ReadStore()
+	if err != nil {
+		t.Fatalf("ReadStore failed: %v", err)
+	}
+	root := Build(s)
+	if root.Kind != TextModel || root.Len != 12 || root.Pos != -1 {
+		t.Fatalf("Unexpected root: %s (len %d)", root, root.Len)
+	}
+	if got := root.Text(); got != "Hello world" {
+		t.Errorf("Expected root text %q, got %q", "Hello world", got)
+	}
+
+	folds := root.Find(func(n *Node) bool { return n.Kind == Fold })
+	if len(folds) != 2 {
+		t.Fatalf("Expected 2 folds, got %d", len(folds))
+	}
+	outer, inner := folds[0], folds[1]
+	if outer.String() != `Fold "outer" at 6` {
+		t.Errorf("Unexpected outer fold: %s", outer)
+	}
+	if outer.Enclosing() != root || outer.Parent.Kind != ViewPiece || outer.Parent.Index != 1 {
+		t.Errorf("Unexpected outer fold placement: parent %s, index %d", outer.Parent, outer.Parent.Index)
+	}
+	if inner.Pos != 2 || inner.Enclosing() != outer.Children[0] {
+		t.Errorf("Expected inner fold at 2 of the hidden text, got %s", inner)
+	}
+	if n := len(inner.Ancestors()); n != 5 {
+		t.Errorf("Expected 5 ancestors of the inner fold, got %d", n)
+	}
+}
@@ -0,0 +1,57 @@
+package dom
+
+import (
+	"strings"
+
+	"odcread/pkg/ruler"
+)
+
+// Paragraph is a paragraph of a text and the format it is shown in.
+type Paragraph struct {
+	Pos   int               // Character position in the text
+	Text  string            // Text without the paragraph separator; embedded views are left out
+	Attrs *ruler.Attributes // Format of the paragraph, or nil for the view's default ruler
+}
+
+// Paragraphs splits a text model node (see Build) into paragraphs.
+// Paragraphs end at line (0DX) and paragraph (0EX) separators. A ruler
+// sets the format of the paragraph it is in and of all that follow it.
+func Paragraphs(text *Node) []Paragraph {
+	var paras []Paragraph
+	var attrs *ruler.Attributes
+	var sb strings.Builder
+	cur := Paragraph{}
+	flush := func(next int) {
+		cur.Text = sb.String()
+		paras = append(paras, cur)
+		sb.Reset()
+		cur = Paragraph{Pos: next, Attrs: attrs}
+	}
+
+	for _, n := range text.Children {
+		switch n.Kind {
+		case ShortPiece, LongPiece:
+			pos := n.Pos
+			for _, r := range n.Text() {
+				pos++
+				if r == '\n' || r == '\x0E' {
+					flush(pos)
+				} else {
+					sb.WriteRune(r)
+				}
+			}
+		case ViewPiece:
+			if len(n.Children) == 0 {
+				continue
+			}
+			if r, ok := n.Children[0].Store.(*ruler.StdRuler); ok && r.GetAttributes() != nil {
+				attrs = r.GetAttributes()
+				cur.Attrs = attrs
+			}
+		}
+	}
+	if sb.Len() > 0 || len(paras) == 0 {
+		flush(text.Len)
+	}
+	return paras
+}
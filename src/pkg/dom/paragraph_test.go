@@ -0,0 +1,34 @@
+package dom
+
+import (
+	"bytes"
+	"testing"
+
+	"odcread/internal/testdoc"
+	"odcread/pkg/reader"
+	"odcread/pkg/ruler"
+)
+
+func TestParagraphs(t *testing.T) {
+	const mm = 36000
+	r := testdoc.Ruler(5*mm, 10*mm, 150*mm, 1<<ruler.LeftAdjust)
+	doc := testdoc.TextModel("Title\r", testdoc.View{Store: r}, "a\tb\rc")
+
+	s, err := reader.NewReader(bytes.NewReader(doc)).ReadStore()
+	if err != nil {
+		t.Fatalf("ReadStore failed: %v", err)
+	}
+	paras := Paragraphs(Build(s))
+	if len(paras) != 3 {
+		t.Fatalf("Expected 3 paragraphs, got %+v", paras)
+	}
+	if paras[0].Text != "Title" || paras[0].Attrs != nil {
+		t.Errorf("Unexpected first paragraph %+v", paras[0])
+	}
+	if paras[1].Text != "a\tb" || paras[1].Pos != 6 || paras[2].Text != "c" || paras[2].Attrs != paras[1].Attrs {
+		t.Errorf("Unexpected paragraphs %+v", paras[1:])
+	}
+	if paras[1].Attrs == nil || paras[1].Attrs.Left != 10*mm {
+		t.Errorf("Expected the ruler's attributes, got %v", paras[1].Attrs)
+	}
+}
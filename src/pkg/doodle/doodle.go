@@ -0,0 +1,483 @@
+// Package doodle provides WinBUGS graphical models (DoodleModels,
+// DoodleNodes, DoodlePlates, DoodleViews): nodes joined by edges and
+// grouped by plates, from which WinBUGS writes the BUGS code of a model.
+//
+// The layouts given for Internalize are reconstructed from what WinBUGS
+// shows and writes for a model. They are not taken from the Externalize
+// procedures of the Doodle modules and have not been checked against
+// files written by WinBUGS, so real doodles may not fit them. A store that
+// does not fit is read as an alien (or, in lenient mode, as a damaged
+// store), and a store that ends elsewhere than where its data ends is
+// reported as a damage by the reader. Undecoded finds such stores. For the
+// same reason, the types are only registered by
+// typeregister.RegisterDoodles, not by default.
+package doodle
+
+import (
+	"fmt"
+
+	"odcread/pkg/alien"
+	"odcread/pkg/document"
+	"odcread/pkg/fold"
+	"odcread/pkg/oberon"
+	"odcread/pkg/store"
+)
+
+const (
+	TypeNameModel = "DoodleModels.Model^"
+	TypeNameNode  = "DoodleNodes.Node^"
+	TypeNamePlate = "DoodlePlates.Plate^"
+	TypeNameView  = "DoodleViews.View^"
+)
+
+// maxItems bounds the number of nodes, edges and plates read from a file.
+const maxItems = 1 << 16
+
+// Kind is the kind of a node.
+type Kind byte
+
+const (
+	Stochastic Kind = iota // Drawn from a distribution, "name ~ density(...)"
+	Logical                // A function of its parents, "name <- value"
+	Constant               // Data, not written to the model code
+)
+
+// String returns the kind as shown in the node's properties.
+func (k Kind) String() string {
+	switch k {
+	case Stochastic:
+		return "stochastic"
+	case Logical:
+		return "logical"
+	case Constant:
+		return "constant"
+	}
+	return fmt.Sprintf("kind %d", byte(k))
+}
+
+// Param is a parameter of a stochastic node's density.
+type Param struct {
+	Name  string // e.g. "mu"
+	Value string // An expression, e.g. "alpha0"
+}
+
+// Node is a node of a graphical model (DoodleNodes.Node).
+type Node struct {
+	store.BaseStore
+	kind         Kind
+	name         string         // e.g. "r[i]"
+	x, y         oberon.Integer // Centre, in universal units
+	density      string         // Stochastic nodes, e.g. "dbin"
+	params       []Param        // Stochastic nodes
+	value        string         // Logical nodes
+	link         string         // Logical nodes: link function, e.g. "logit", or "" for identity
+	lower, upper string         // Stochastic nodes: bounds, or "" for none
+}
+
+// NewNode creates a new Node instance.
+func NewNode(id oberon.Integer) *Node {
+	return &Node{
+		BaseStore: store.NewBaseStore(id),
+	}
+}
+
+// GetTypeName returns the type name for Node.
+func (n *Node) GetTypeName() string {
+	return TypeNameNode
+}
+
+// Internalize reads Node data from the reader.
+// Assumed format (see the package comment):
+//
+//	version (0..1)
+//	kind (byte), name (xstring), x, y (int)
+//	density (xstring), number of parameters (int),
+//	then for each parameter: name, value (xstring)
+//	value, link (xstring)
+//	lower, upper (xstring), if version 1
+func (n *Node) Internalize(reader store.Reader) error {
+	if err := n.BaseStore.Internalize(reader); err != nil {
+		return err
+	}
+	version, err := reader.ReadVersion(0, 1)
+	if err != nil {
+		return err
+	}
+
+	kind, err := reader.ReadByte()
+	if err != nil {
+		return fmt.Errorf("failed to read node kind: %w", err)
+	}
+	if Kind(kind) > Constant {
+		return fmt.Errorf("invalid node kind %d", kind)
+	}
+	n.kind = Kind(kind)
+	if n.name, err = reader.ReadXString(); err != nil {
+		return fmt.Errorf("failed to read node name: %w", err)
+	}
+	if n.x, err = reader.ReadInt(); err != nil {
+		return fmt.Errorf("failed to read position of node %s: %w", n.name, err)
+	}
+	if n.y, err = reader.ReadInt(); err != nil {
+		return fmt.Errorf("failed to read position of node %s: %w", n.name, err)
+	}
+
+	if n.density, err = reader.ReadXString(); err != nil {
+		return fmt.Errorf("failed to read density of node %s: %w", n.name, err)
+	}
+	count, err := reader.ReadInt()
+	if err != nil {
+		return fmt.Errorf("failed to read parameters of node %s: %w", n.name, err)
+	}
+	if count < 0 || count > maxItems {
+		return fmt.Errorf("invalid number of parameters %d of node %s", count, n.name)
+	}
+	n.params = make([]Param, count)
+	for i := range n.params {
+		for _, p := range []*string{&n.params[i].Name, &n.params[i].Value} {
+			if *p, err = reader.ReadXString(); err != nil {
+				return fmt.Errorf("failed to read parameter %d of node %s: %w", i+1, n.name, err)
+			}
+		}
+	}
+
+	fields := []*string{&n.value, &n.link}
+	if version > 0 {
+		fields = append(fields, &n.lower, &n.upper)
+	}
+	for _, p := range fields {
+		if *p, err = reader.ReadXString(); err != nil {
+			return fmt.Errorf("failed to read properties of node %s: %w", n.name, err)
+		}
+	}
+	return nil
+}
+
+// String returns a string representation of the Node.
+func (n *Node) String() string {
+	return fmt.Sprintf("DoodleNode{id: %d, %s %s}", n.GetID(), n.kind, n.name)
+}
+
+// GetKind returns the kind of the node.
+func (n *Node) GetKind() Kind {
+	return n.kind
+}
+
+// GetName returns the name of the node, with its indices, e.g. "r[i]".
+func (n *Node) GetName() string {
+	return n.name
+}
+
+// GetPosition returns the centre of the node, in universal units.
+func (n *Node) GetPosition() (x, y oberon.Integer) {
+	return n.x, n.y
+}
+
+// GetDensity returns the density of a stochastic node, e.g. "dnorm".
+func (n *Node) GetDensity() string {
+	return n.density
+}
+
+// GetParams returns the parameters of a stochastic node's density.
+func (n *Node) GetParams() []Param {
+	return n.params
+}
+
+// GetValue returns the expression of a logical node.
+func (n *Node) GetValue() string {
+	return n.value
+}
+
+// GetLink returns the link function of a logical node, or "".
+func (n *Node) GetLink() string {
+	return n.link
+}
+
+// GetBounds returns the bounds of a stochastic node; "" means none.
+func (n *Node) GetBounds() (lower, upper string) {
+	return n.lower, n.upper
+}
+
+// Plate is a plate of a graphical model (DoodlePlates.Plate): the nodes
+// inside it are repeated for each value of its index.
+type Plate struct {
+	store.BaseStore
+	index    string // e.g. "i"
+	from, to string // Expressions, e.g. "1" and "N"
+	bounds   document.Rect
+}
+
+// NewPlate creates a new Plate instance.
+func NewPlate(id oberon.Integer) *Plate {
+	return &Plate{
+		BaseStore: store.NewBaseStore(id),
+	}
+}
+
+// GetTypeName returns the type name for Plate.
+func (p *Plate) GetTypeName() string {
+	return TypeNamePlate
+}
+
+// Internalize reads Plate data from the reader.
+// Assumed format (see the package comment):
+//
+//	version (0)
+//	index, from, to (xstring)
+//	l, t, r, b (int)
+func (p *Plate) Internalize(reader store.Reader) error {
+	if err := p.BaseStore.Internalize(reader); err != nil {
+		return err
+	}
+	if _, err := reader.ReadVersion(0, 0); err != nil {
+		return err
+	}
+
+	var err error
+	for _, s := range []*string{&p.index, &p.from, &p.to} {
+		if *s, err = reader.ReadXString(); err != nil {
+			return fmt.Errorf("failed to read plate index: %w", err)
+		}
+	}
+	for _, v := range []*oberon.Integer{&p.bounds.Left, &p.bounds.Top, &p.bounds.Right, &p.bounds.Bottom} {
+		if *v, err = reader.ReadInt(); err != nil {
+			return fmt.Errorf("failed to read bounds of plate %s: %w", p.index, err)
+		}
+	}
+	return nil
+}
+
+// String returns a string representation of the Plate.
+func (p *Plate) String() string {
+	return fmt.Sprintf("DoodlePlate{id: %d, %s in %s : %s}", p.GetID(), p.index, p.from, p.to)
+}
+
+// GetIndex returns the index of the plate and its range, e.g. "i", "1", "N".
+func (p *Plate) GetIndex() (index, from, to string) {
+	return p.index, p.from, p.to
+}
+
+// GetBounds returns the bounds of the plate, in universal units.
+func (p *Plate) GetBounds() document.Rect {
+	return p.bounds
+}
+
+// contains reports whether the point x, y lies in the plate.
+func (p *Plate) contains(x, y oberon.Integer) bool {
+	b := p.bounds
+	return b.Left <= x && x < b.Right && b.Top <= y && y < b.Bottom
+}
+
+// Edge joins a parent node to a child node. The nodes are indices into
+// Model.GetNodes.
+type Edge struct {
+	From, To int
+}
+
+// Model is a graphical model (DoodleModels.Model).
+type Model struct {
+	store.Model
+	nodes  []*Node
+	edges  []Edge
+	plates []*Plate
+}
+
+// NewModel creates a new Model instance.
+func NewModel(id oberon.Integer) *Model {
+	return &Model{
+		Model: *store.NewModel(id),
+	}
+}
+
+// GetTypeName returns the type name for Model.
+func (m *Model) GetTypeName() string {
+	return TypeNameModel
+}
+
+// Internalize reads Model data from the reader. A model with a node or
+// plate that is an alien is turned into an alien itself.
+// Assumed format (see the package comment):
+//
+//	version (0)
+//	number of nodes (int), then each node (store)
+//	number of edges (int), then for each edge: parent, child (int)
+//	number of plates (int), then each plate (store)
+func (m *Model) Internalize(reader store.Reader) error {
+	if err := m.Model.Internalize(reader); err != nil {
+		return err
+	}
+	if _, err := reader.ReadVersion(0, 0); err != nil {
+		return err
+	}
+
+	n, err := readCount(reader, "nodes")
+	if err != nil {
+		return err
+	}
+	// Items are appended only once read, so that a model salvaged in
+	// lenient mode holds no gaps.
+	m.nodes = make([]*Node, 0, n)
+	for i := 0; i < n; i++ {
+		s, err := reader.ReadStore()
+		if err != nil {
+			return fmt.Errorf("failed to read node %d: %w", i+1, err)
+		}
+		node, ok := s.(*Node)
+		if !ok {
+			return m.unknown(reader, s, "node", i)
+		}
+		m.nodes = append(m.nodes, node)
+	}
+
+	if n, err = readCount(reader, "edges"); err != nil {
+		return err
+	}
+	m.edges = make([]Edge, 0, n)
+	for i := 0; i < n; i++ {
+		var e Edge
+		for _, p := range []*int{&e.From, &e.To} {
+			v, err := reader.ReadInt()
+			if err != nil {
+				return fmt.Errorf("failed to read edge %d: %w", i+1, err)
+			}
+			if v < 0 || int(v) >= len(m.nodes) {
+				return fmt.Errorf("edge %d refers to node %d of %d", i+1, v, len(m.nodes))
+			}
+			*p = int(v)
+		}
+		m.edges = append(m.edges, e)
+	}
+
+	if n, err = readCount(reader, "plates"); err != nil {
+		return err
+	}
+	m.plates = make([]*Plate, 0, n)
+	for i := 0; i < n; i++ {
+		s, err := reader.ReadStore()
+		if err != nil {
+			return fmt.Errorf("failed to read plate %d: %w", i+1, err)
+		}
+		plate, ok := s.(*Plate)
+		if !ok {
+			return m.unknown(reader, s, "plate", i)
+		}
+		m.plates = append(m.plates, plate)
+	}
+	return nil
+}
+
+// unknown handles the store s read in place of the ith node or plate.
+func (m *Model) unknown(reader store.Reader, s store.Store, what string, i int) error {
+	if _, ok := s.(*alien.Alien); ok {
+		return reader.TurnIntoAlien(store.AlienComponent)
+	}
+	if s == nil {
+		return fmt.Errorf("%s %d is nil", what, i+1)
+	}
+	return fmt.Errorf("%s %d is a %s", what, i+1, s.GetTypeName())
+}
+
+// String returns a string representation of the Model.
+func (m *Model) String() string {
+	return fmt.Sprintf("DoodleModel{id: %d, nodes: %d, edges: %d, plates: %d}", m.GetID(), len(m.nodes), len(m.edges), len(m.plates))
+}
+
+// GetNodes returns the nodes of the model.
+func (m *Model) GetNodes() []*Node {
+	return m.nodes
+}
+
+// GetEdges returns the edges of the model.
+func (m *Model) GetEdges() []Edge {
+	return m.edges
+}
+
+// GetPlates returns the plates of the model.
+func (m *Model) GetPlates() []*Plate {
+	return m.plates
+}
+
+// Children returns the nodes and the plates.
+func (m *Model) Children() []store.Store {
+	list := make([]store.Store, 0, len(m.nodes)+len(m.plates))
+	for _, n := range m.nodes {
+		list = append(list, n)
+	}
+	for _, p := range m.plates {
+		list = append(list, p)
+	}
+	return list
+}
+
+// View shows a graphical model (DoodleViews.View).
+type View struct {
+	fold.View
+	model store.Store
+}
+
+// NewView creates a new View instance.
+func NewView(id oberon.Integer) *View {
+	return &View{
+		View: *fold.NewView(id),
+	}
+}
+
+// GetTypeName returns the type name for View.
+func (v *View) GetTypeName() string {
+	return TypeNameView
+}
+
+// Internalize reads View data from the reader. A view whose model is an
+// alien is turned into an alien itself.
+// Assumed format (see the package comment):
+//
+//	version (0)
+//	model (store)
+func (v *View) Internalize(reader store.Reader) error {
+	if err := v.View.Internalize(reader); err != nil {
+		return err
+	}
+	if _, err := reader.ReadVersion(0, 0); err != nil {
+		return err
+	}
+
+	model, err := reader.ReadStore()
+	if err != nil {
+		return fmt.Errorf("failed to read doodle model: %w", err)
+	}
+	switch model.(type) {
+	case nil:
+		return fmt.Errorf("doodle view has no model")
+	case *alien.Alien:
+		return reader.TurnIntoAlien(store.AlienComponent)
+	}
+	v.model = model
+	return nil
+}
+
+// String returns a string representation of the View.
+func (v *View) String() string {
+	return fmt.Sprintf("DoodleView{id: %d}", v.GetID())
+}
+
+// GetModel returns the model shown, normally a *Model.
+func (v *View) GetModel() store.Store {
+	return v.model
+}
+
+// Children returns the model.
+func (v *View) Children() []store.Store {
+	return []store.Store{v.model}
+}
+
+// readCount reads the number of items of a list.
+func readCount(reader store.Reader, what string) (int, error) {
+	n, err := reader.ReadInt()
+	if err != nil {
+		return 0, fmt.Errorf("failed to read number of %s: %w", what, err)
+	}
+	if n < 0 || n > maxItems {
+		return 0, fmt.Errorf("invalid number of %s %d", what, n)
+	}
+	return int(n), nil
+}
@@ -0,0 +1,192 @@
+package doodle_test
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"odcread/internal/testdoc"
+	"odcread/pkg/alien"
+	"odcread/pkg/doodle"
+	"odcread/pkg/extract"
+	"odcread/pkg/reader"
+	"odcread/pkg/store"
+	"odcread/pkg/typeregister"
+)
+
+func init() {
+	typeregister.RegisterDoodles() // They are not registered by default
+}
+
+// model encodes a random effects model with a plate nested in another.
+func model() []byte {
+	nodes := []testdoc.DoodleNode{
+		{Kind: 0, Name: "alpha0", X: 10, Y: 10, Density: "dnorm", Params: [][2]string{{"mu", "0.0"}, {"tau", "1.0E-6"}}},
+		{Kind: 0, Name: "tau", X: 20, Y: 10, Density: "dgamma", Params: [][2]string{{"r", "0.001"}, {"mu", "0.001"}}},
+		{Kind: 1, Name: "p[i]", X: 50, Y: 50, Value: "alpha0 + b[i]", Link: "logit"},
+		{Kind: 0, Name: "b[i]", X: 60, Y: 50, Density: "dnorm", Params: [][2]string{{"mu", "0"}, {"tau", "tau"}}},
+		{Kind: 0, Name: "r[i]", X: 50, Y: 80, Density: "dbin", Params: [][2]string{{"p", "p[i]"}, {"n", "n[i]"}}},
+		{Kind: 2, Name: "n[i]", X: 70, Y: 80},
+		{Kind: 0, Name: "y[i, j]", X: 80, Y: 90, Density: "dnorm", Params: [][2]string{{"mu", "b[i]"}, {"tau", "tau"}}, Bounds: [2]string{"0", ""}},
+	}
+	edges := [][2]int32{{0, 2}, {3, 2}, {1, 3}, {2, 4}, {5, 4}, {3, 6}, {1, 6}}
+	plates := []testdoc.DoodlePlate{
+		{Index: "j", From: "1", To: "M", Bounds: [4]int32{75, 85, 150, 150}},
+		{Index: "i", From: "1", To: "N", Bounds: [4]int32{40, 40, 200, 200}},
+	}
+	return testdoc.Doodle(nodes, edges, plates)
+}
+
+func read(t *testing.T, doc []byte) store.Store {
+	t.Helper()
+	s, err := reader.NewReader(bytes.NewReader(doc)).ReadStore()
+	if err != nil {
+		t.Fatalf("ReadStore failed: %v", err)
+	}
+	return s
+}
+
+func TestModel(t *testing.T) {
+	s := read(t, testdoc.DoodleView(model()))
+	if _, ok := s.(*doodle.View); !ok {
+		t.Fatalf("Expected *doodle.View, got %T", s)
+	}
+	models := doodle.Models(s)
+	if len(models) != 1 {
+		t.Fatalf("Expected 1 model, got %d", len(models))
+	}
+	m := models[0]
+	if len(m.GetNodes()) != 7 || len(m.GetEdges()) != 7 || len(m.GetPlates()) != 2 {
+		t.Fatalf("Unexpected model %s", m)
+	}
+	p := m.GetNodes()[2]
+	if p.GetKind() != doodle.Logical || p.GetLink() != "logit" || p.GetValue() != "alpha0 + b[i]" {
+		t.Errorf("Unexpected node %s: link %q, value %q", p, p.GetLink(), p.GetValue())
+	}
+
+	var code strings.Builder
+	if err := doodle.WriteBUGS(&code, m); err != nil {
+		t.Fatalf("WriteBUGS failed: %v", err)
+	}
+	want := `model {
+	alpha0 ~ dnorm(0.0, 1.0E-6)
+	tau ~ dgamma(0.001, 0.001)
+	for (i in 1 : N) {
+		logit(p[i]) <- alpha0 + b[i]
+		b[i] ~ dnorm(0, tau)
+		r[i] ~ dbin(p[i], n[i])
+		for (j in 1 : M) {
+			y[i, j] ~ dnorm(b[i], tau) I(0,)
+		}
+	}
+}
+`
+	if code.String() != want {
+		t.Errorf("Expected\n%s\ngot\n%s", want, code.String())
+	}
+
+	var dot strings.Builder
+	if err := doodle.WriteDOT(&dot, m); err != nil {
+		t.Fatalf("WriteDOT failed: %v", err)
+	}
+	for _, line := range []string{
+		"\tn0 [label=\"alpha0\", shape=ellipse];\n",
+		"\tsubgraph cluster_0 {\n\t\tlabel=\"for (i in 1 : N)\";\n",
+		"\t\tn5 [label=\"n[i]\", shape=box];\n",
+		"\t\tsubgraph cluster_1 {\n\t\t\tlabel=\"for (j in 1 : M)\";\n\t\t\tn6 ",
+		"\tn0 -> n2 [arrowhead=empty];\n",
+		"\tn2 -> n4;\n",
+	} {
+		if !strings.Contains(dot.String(), line) {
+			t.Errorf("Expected DOT to contain %q, got\n%s", line, dot.String())
+		}
+	}
+}
+
+func TestModel_AlienNode(t *testing.T) {
+	// A node of an unknown type makes the model, and so the view, an alien
+	parts := []interface{}{
+		testdoc.Raw{0, 0, 0, 0, 1, 0, 0, 0}, // versions, 1 node
+		testdoc.Opaque("DoodleNodes.CensoredNodeDesc"),
+		testdoc.Raw{0, 0, 0, 0, 0, 0, 0, 0}, // no edges, no plates
+	}
+	content, downOff := testdoc.Content(parts...)
+	path := []string{"DoodleModels.ModelDesc", "Models.ModelDesc", "Stores.ElemDesc", "Stores.StoreDesc"}
+	m := testdoc.Store(byte(store.ELEM), path, content, downOff)
+
+	s := read(t, testdoc.DoodleView(m))
+	if _, ok := s.(*alien.Alien); !ok {
+		t.Fatalf("Expected an alien, got %T", s)
+	}
+	if len(doodle.Models(s)) != 0 {
+		t.Errorf("Expected no models in an alien")
+	}
+	if u := doodle.Undecoded(s); len(u) != 3 || u[0] != s {
+		t.Errorf("Expected the view, its model and the node to be undecoded, got %v", u)
+	}
+}
+
+func TestModel_Truncated(t *testing.T) {
+	// A model cut off among its nodes keeps the nodes read before the cut
+	doc := testdoc.DoodleView(model())
+	r := reader.NewReader(bytes.NewReader(doc[:len(doc)/2]))
+	r.SetLenient(true)
+	s, err := r.ReadStore()
+	if err != nil {
+		t.Fatalf("ReadStore failed: %v", err)
+	}
+	if len(r.Damages()) == 0 {
+		t.Errorf("Expected damages")
+	}
+	models := doodle.Models(s)
+	if len(models) != 1 {
+		t.Fatalf("Expected 1 model, got %d", len(models))
+	}
+	m := models[0]
+	nodes := m.GetNodes()
+	if len(nodes) == 0 || len(nodes) >= 7 || len(m.GetEdges()) != 0 || len(m.GetPlates()) != 0 {
+		t.Fatalf("Unexpected partial model %s", m)
+	}
+	for i, n := range nodes {
+		if n == nil {
+			t.Fatalf("Node %d is nil", i)
+		}
+	}
+
+	var code strings.Builder
+	if err := doodle.WriteBUGS(&code, m); err != nil {
+		t.Fatalf("WriteBUGS failed: %v", err)
+	}
+	if !strings.Contains(code.String(), "\talpha0 ~ dnorm(0.0, 1.0E-6)\n") {
+		t.Errorf("Expected the nodes read to be written, got\n%s", code.String())
+	}
+	var dot strings.Builder
+	if err := doodle.WriteDOT(&dot, m); err != nil {
+		t.Fatalf("WriteDOT failed: %v", err)
+	}
+	if !strings.Contains(dot.String(), "\tn0 [label=\"alpha0\", shape=ellipse];\n") {
+		t.Errorf("Expected the nodes read to be drawn, got\n%s", dot.String())
+	}
+}
+
+func TestModel_Mismatch(t *testing.T) {
+	// A doodle that does not fit the assumed layout is read as an alien
+	// and the text around it is still read
+	nodes := []testdoc.DoodleNode{{Kind: 9, Name: "x"}}
+	view := testdoc.DoodleView(testdoc.Doodle(nodes, nil, nil))
+	r := reader.NewReader(bytes.NewReader(testdoc.TextModel("Before ", testdoc.View{Store: view}, " after")))
+	s, err := r.ReadStore()
+	if err != nil {
+		t.Fatalf("ReadStore failed: %v", err)
+	}
+	if got := extract.Extract(s).Text; got != "Before  after" {
+		t.Errorf("Expected the surrounding text, got %q", got)
+	}
+	if len(doodle.Models(s)) != 0 || len(doodle.Undecoded(s)) == 0 {
+		t.Errorf("Expected the doodle to be undecoded")
+	}
+	damages := r.Damages()
+	if len(damages) != 1 || !strings.Contains(damages[0].String(), "invalid node kind 9") {
+		t.Errorf("Expected a damage for the node, got %v", damages)
+	}
+}
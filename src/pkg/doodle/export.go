@@ -0,0 +1,217 @@
+package doodle
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+
+	"odcread/pkg/alien"
+	"odcread/pkg/document"
+	"odcread/pkg/store"
+	"odcread/pkg/visitor"
+)
+
+// Models returns the graphical models in the tree rooted at root, in
+// document order.
+func Models(root store.Store) []*Model {
+	var list []*Model
+	visitor.Walk(root, func(a visitor.Ancestry) visitor.Action {
+		if m, ok := a.Store().(*Model); ok {
+			list = append(list, m)
+			return visitor.SkipChildren
+		}
+		return visitor.Continue
+	})
+	return list
+}
+
+// Undecoded returns the stores of the Doodle modules in the tree rooted at
+// root that were read as aliens or damaged stores, in document order. As
+// the layout of doodles is not verified (see the package comment), these
+// may be doodles that WinBUGS wrote differently.
+func Undecoded(root store.Store) []store.Store {
+	var list []store.Store
+	visitor.Walk(root, func(a visitor.Ancestry) visitor.Action {
+		var path store.TypePath
+		switch s := a.Store().(type) {
+		case *alien.Alien:
+			path = s.GetTypePath()
+		case *alien.Damaged:
+			path = s.GetTypePath()
+		}
+		if len(path) > 0 && isDoodleType(path[0]) {
+			list = append(list, a.Store())
+		}
+		return visitor.Continue
+	})
+	return list
+}
+
+// isDoodleType reports whether name is a type of one of the Doodle modules.
+func isDoodleType(name string) bool {
+	module, _, _ := strings.Cut(name, ".")
+	switch module {
+	case "DoodleModels", "DoodleNodes", "DoodlePlates", "DoodleViews":
+		return true
+	}
+	return false
+}
+
+// scope is the top level of a model or a plate, with the nodes and plates
+// directly inside it.
+type scope struct {
+	plate  *Plate // nil at the top level
+	nodes  []*Node
+	scopes []*scope
+}
+
+// scopes nests the plates of the model and places its nodes, as WinBUGS
+// does: a plate is inside the smallest plate that encloses it, and a node
+// inside the smallest plate that contains its centre. Missing nodes and
+// plates of a damaged model are left out.
+func (m *Model) scopes() *scope {
+	top := &scope{}
+	byPlate := map[*Plate]*scope{nil: top}
+	var plates []*Plate
+	for _, p := range m.plates {
+		if p != nil {
+			plates = append(plates, p)
+			byPlate[p] = &scope{plate: p}
+		}
+	}
+	// smallest returns the smallest plate other than self for which in holds
+	smallest := func(self *Plate, in func(*Plate) bool) *Plate {
+		var best *Plate
+		for _, p := range plates {
+			if p != self && in(p) && (best == nil || area(p.bounds) < area(best.bounds)) {
+				best = p
+			}
+		}
+		return best
+	}
+	for _, p := range plates {
+		outer := smallest(p, func(q *Plate) bool { return encloses(q.bounds, p.bounds) && area(q.bounds) > area(p.bounds) })
+		byPlate[outer].scopes = append(byPlate[outer].scopes, byPlate[p])
+	}
+	for _, n := range m.nodes {
+		if n == nil {
+			continue
+		}
+		in := smallest(nil, func(q *Plate) bool { return q.contains(n.x, n.y) })
+		byPlate[in].nodes = append(byPlate[in].nodes, n)
+	}
+	return top
+}
+
+func area(r document.Rect) int64 {
+	return int64(r.Right-r.Left) * int64(r.Bottom-r.Top)
+}
+
+// encloses reports whether r lies within outer.
+func encloses(outer, r document.Rect) bool {
+	return outer.Left <= r.Left && r.Right <= outer.Right && outer.Top <= r.Top && r.Bottom <= outer.Bottom
+}
+
+// WriteBUGS writes the BUGS code of the model, as WinBUGS's "write code"
+// command does: a statement for each stochastic and logical node, inside
+// a for loop for each plate. Constants are data and are left out.
+func WriteBUGS(w io.Writer, m *Model) error {
+	bw := bufio.NewWriter(w)
+	bw.WriteString("model {\n")
+	writeScope(bw, m.scopes(), 1)
+	bw.WriteString("}\n")
+	return bw.Flush()
+}
+
+func writeScope(bw *bufio.Writer, s *scope, depth int) {
+	indent := strings.Repeat("\t", depth)
+	for _, n := range s.nodes {
+		if stmt := n.statement(); stmt != "" {
+			bw.WriteString(indent + stmt + "\n")
+		}
+	}
+	for _, inner := range s.scopes {
+		fmt.Fprintf(bw, "%sfor (%s in %s : %s) {\n", indent, inner.plate.index, inner.plate.from, inner.plate.to)
+		writeScope(bw, inner, depth+1)
+		bw.WriteString(indent + "}\n")
+	}
+}
+
+// statement returns the BUGS statement that defines the node, or "" for
+// constants.
+func (n *Node) statement() string {
+	switch n.kind {
+	case Stochastic:
+		values := make([]string, len(n.params))
+		for i, p := range n.params {
+			values[i] = p.Value
+		}
+		stmt := fmt.Sprintf("%s ~ %s(%s)", n.name, n.density, strings.Join(values, ", "))
+		if n.lower != "" || n.upper != "" {
+			stmt += fmt.Sprintf(" I(%s,%s)", n.lower, n.upper)
+		}
+		return stmt
+	case Logical:
+		if n.link != "" {
+			return fmt.Sprintf("%s(%s) <- %s", n.link, n.name, n.value)
+		}
+		return fmt.Sprintf("%s <- %s", n.name, n.value)
+	}
+	return ""
+}
+
+// WriteDOT writes the graph of the model in the DOT language of Graphviz,
+// drawn as in WinBUGS: constants as boxes, other nodes as ellipses, edges
+// into logical nodes with hollow arrowheads and plates as clusters labelled
+// with their loops.
+func WriteDOT(w io.Writer, m *Model) error {
+	bw := bufio.NewWriter(w)
+	ids := make(map[*Node]int, len(m.nodes))
+	for i, n := range m.nodes {
+		ids[n] = i
+	}
+	bw.WriteString("digraph model {\n")
+	cluster := 0
+	var writeNodes func(s *scope, indent string)
+	writeNodes = func(s *scope, indent string) {
+		for _, n := range s.nodes {
+			shape := "ellipse"
+			if n.kind == Constant {
+				shape = "box"
+			}
+			fmt.Fprintf(bw, "%sn%d [label=%s, shape=%s];\n", indent, ids[n], quote(n.name), shape)
+		}
+		for _, inner := range s.scopes {
+			p := inner.plate
+			fmt.Fprintf(bw, "%ssubgraph cluster_%d {\n", indent, cluster)
+			cluster++
+			fmt.Fprintf(bw, "%s\tlabel=%s;\n", indent, quote(fmt.Sprintf("for (%s in %s : %s)", p.index, p.from, p.to)))
+			writeNodes(inner, indent+"\t")
+			bw.WriteString(indent + "}\n")
+		}
+	}
+	writeNodes(m.scopes(), "\t")
+	for _, e := range m.edges {
+		if !m.hasNode(e.From) || !m.hasNode(e.To) {
+			continue
+		}
+		fmt.Fprintf(bw, "\tn%d -> n%d", e.From, e.To)
+		if m.nodes[e.To].kind == Logical {
+			bw.WriteString(" [arrowhead=empty]")
+		}
+		bw.WriteString(";\n")
+	}
+	bw.WriteString("}\n")
+	return bw.Flush()
+}
+
+// hasNode reports whether the model has a node at index i.
+func (m *Model) hasNode(i int) bool {
+	return i >= 0 && i < len(m.nodes) && m.nodes[i] != nil
+}
+
+// quote returns s as a DOT string.
+func quote(s string) string {
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`).Replace(s) + `"`
+}
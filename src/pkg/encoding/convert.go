@@ -7,6 +7,7 @@ import (
 	"fmt"
 	"io"
 	"strings"
+	"unicode/utf8"
 
 	"golang.org/x/text/encoding/charmap"
 	"golang.org/x/text/encoding/unicode"
@@ -57,16 +58,14 @@ func ConvertUCS2(input []oberon.Char) (string, error) {
 	}
 
 	// Convert uint16 slice to bytes (little-endian)
-	buf := new(bytes.Buffer)
-	for _, ch := range input[:length] {
-		if err := binary.Write(buf, binary.LittleEndian, ch); err != nil {
-			return "", fmt.Errorf("failed to write UCS-2 char: %w", err)
-		}
+	buf := make([]byte, 2*length)
+	for i, ch := range input[:length] {
+		binary.LittleEndian.PutUint16(buf[2*i:], ch)
 	}
 
 	// UTF-16 decoder (UCS-2 is a subset of UTF-16)
 	decoder := unicode.UTF16(unicode.LittleEndian, unicode.IgnoreBOM).NewDecoder()
-	reader := transform.NewReader(buf, decoder)
+	reader := transform.NewReader(bytes.NewReader(buf), decoder)
 
 	result, err := io.ReadAll(reader)
 	if err != nil {
@@ -77,3 +76,16 @@ func ConvertUCS2(input []oberon.Char) (string, error) {
 	output := strings.ReplaceAll(string(result), "\r", "\n")
 	return output, nil
 }
+
+// DecodeXString converts the bytes of an X-string to UTF-8. Current files store X-strings as UTF-8, older ones as
+// Latin-1; strings that are not valid UTF-8 are taken to be Latin-1.
+func DecodeXString(s string) string {
+	if utf8.ValidString(s) {
+		return s
+	}
+	runes := make([]rune, len(s))
+	for i := 0; i < len(s); i++ {
+		runes[i] = rune(s[i])
+	}
+	return string(runes)
+}
@@ -0,0 +1,228 @@
+package extract
+
+import (
+	"bufio"
+	"fmt"
+	"html"
+	"io"
+	"sort"
+	"strings"
+
+	"odcread/pkg/document"
+	"odcread/pkg/ruler"
+)
+
+// style renders the text of a document in an output format.
+type style interface {
+	text(s string) string
+	link(text string, l Link) string
+	anchor(ident string) string
+	image(src string) string
+}
+
+// WriteMarkdown writes the documents as Markdown, one paragraph per
+// Markdown paragraph; empty paragraphs are left out and headings, such as
+// tab labels, become level 3 headings. Links to other documents point to ".md" files.
+func WriteMarkdown(w io.Writer, docs ...*Document) error {
+	bw := bufio.NewWriter(w)
+	first := true
+	for _, d := range docs {
+		for _, p := range d.Paragraphs {
+			line := d.inline(p.Start, p.End, markdown{})
+			if strings.TrimSpace(line) == "" {
+				continue
+			}
+			if !first {
+				bw.WriteString("\n")
+			}
+			first = false
+			if p.Heading {
+				line = "### " + line
+			}
+			bw.WriteString(line + "\n")
+		}
+	}
+	return bw.Flush()
+}
+
+// WriteHTML writes the documents as one HTML page with the given title;
+// empty paragraphs are left out and headings become h3 elements. The page
+// setup of the first document becomes the @page rule, and paragraph
+// formats become inline styles. Links to other documents point to ".html"
+// files.
+func WriteHTML(w io.Writer, title string, docs ...*Document) error {
+	bw := bufio.NewWriter(w)
+	fmt.Fprintf(bw, "<!DOCTYPE html>\n<html>\n<head>\n<meta charset=\"utf-8\">\n<title>%s</title>\n", html.EscapeString(title))
+	if len(docs) > 0 && docs[0].Page != nil {
+		fmt.Fprintf(bw, "<style>%s</style>\n", pageRule(*docs[0].Page))
+	}
+	bw.WriteString("</head>\n<body>\n")
+	for _, d := range docs {
+		for _, p := range d.Paragraphs {
+			line := d.inline(p.Start, p.End, htmlStyle{})
+			if strings.TrimSpace(line) == "" {
+				continue
+			}
+			if p.Heading {
+				fmt.Fprintf(bw, "<h3>%s</h3>\n", line)
+				continue
+			}
+			fmt.Fprintf(bw, "<p%s>%s</p>\n", paragraphStyle(p.Attrs), line)
+		}
+	}
+	bw.WriteString("</body>\n</html>\n")
+	return bw.Flush()
+}
+
+// inline renders Text[a:b] with the links, anchors and images in that range.
+// Anchors of targets that start at b, the end of a paragraph, are included.
+// Images that were not written to files (Image.Src is "") are left out.
+func (d *Document) inline(a, b int, st style) string {
+	cuts := []int{a, b}
+	for _, l := range d.Links {
+		cuts = append(cuts, l.Start, l.End)
+	}
+	for _, t := range d.Targets {
+		cuts = append(cuts, t.Start)
+	}
+	for _, img := range d.Images {
+		cuts = append(cuts, img.Pos)
+	}
+	sort.Ints(cuts)
+	images := func(sb *strings.Builder, pos int) {
+		for _, img := range d.Images {
+			if img.Pos == pos && img.Src != "" {
+				sb.WriteString(st.image(img.Src))
+			}
+		}
+	}
+
+	var sb strings.Builder
+	for _, t := range d.Targets {
+		if t.Start == a {
+			sb.WriteString(st.anchor(t.Ident))
+		}
+	}
+	for i := 0; i+1 < len(cuts); i++ {
+		from, to := cuts[i], cuts[i+1]
+		if from < a || to > b || from == to {
+			continue
+		}
+		images(&sb, from)
+		text := st.text(d.Text[from:to])
+		for _, l := range d.Links {
+			if l.Start <= from && to <= l.End {
+				text = st.link(text, l)
+				break
+			}
+		}
+		sb.WriteString(text)
+		for _, t := range d.Targets {
+			if t.Start == to {
+				sb.WriteString(st.anchor(t.Ident))
+			}
+		}
+	}
+	images(&sb, b)
+	return sb.String()
+}
+
+// markdown renders Markdown.
+type markdown struct{}
+
+var markdownEscaper = strings.NewReplacer(
+	`\`, `\\`, "`", "\\`", "*", `\*`, "_", `\_`, "[", `\[`, "]", `\]`, "<", `&lt;`, "\t", " ",
+)
+
+func (markdown) text(s string) string {
+	s = markdownEscaper.Replace(s)
+	if strings.HasPrefix(s, "#") || strings.HasPrefix(s, ">") || strings.HasPrefix(s, "-") || strings.HasPrefix(s, "+") {
+		s = `\` + s
+	}
+	return s
+}
+
+func (markdown) link(text string, l Link) string {
+	if h := l.Href(".md"); h != "" {
+		return fmt.Sprintf("[%s](%s)", text, strings.ReplaceAll(h, " ", "%20"))
+	}
+	return text
+}
+
+func (markdown) anchor(ident string) string {
+	return fmt.Sprintf(`<a id="%s"></a>`, html.EscapeString(ident))
+}
+
+func (markdown) image(src string) string {
+	if !displayable(src) {
+		return fmt.Sprintf("[picture](%s)", strings.ReplaceAll(src, " ", "%20"))
+	}
+	return fmt.Sprintf("![](%s)", strings.ReplaceAll(src, " ", "%20"))
+}
+
+// htmlStyle renders HTML.
+type htmlStyle struct{}
+
+func (htmlStyle) text(s string) string {
+	return html.EscapeString(s)
+}
+
+func (htmlStyle) link(text string, l Link) string {
+	if h := l.Href(".html"); h != "" {
+		return fmt.Sprintf(`<a href="%s">%s</a>`, html.EscapeString(h), text)
+	}
+	return fmt.Sprintf(`<span class="command" title="%s">%s</span>`, html.EscapeString(l.Command), text)
+}
+
+func (htmlStyle) anchor(ident string) string {
+	return fmt.Sprintf(`<a id="%s"></a>`, html.EscapeString(ident))
+}
+
+func (htmlStyle) image(src string) string {
+	if !displayable(src) {
+		return fmt.Sprintf(`<a href="%s">picture</a>`, html.EscapeString(src))
+	}
+	return fmt.Sprintf(`<img src="%s" alt="">`, html.EscapeString(src))
+}
+
+// displayable reports whether browsers can show the picture file src;
+// other pictures, such as metafiles, are linked to instead.
+func displayable(src string) bool {
+	return strings.HasSuffix(src, ".png") || strings.HasSuffix(src, ".bmp")
+}
+
+// pageRule returns the CSS @page rule for a page setup.
+func pageRule(p document.Page) string {
+	l, t, r, b := p.Margins()
+	return fmt.Sprintf("@page { size: %smm %smm; margin: %smm %smm %smm %smm; }",
+		document.MM(p.Width), document.MM(p.Height), document.MM(t), document.MM(r), document.MM(b), document.MM(l))
+}
+
+// paragraphStyle returns the style attribute for a paragraph format.
+func paragraphStyle(a *ruler.Attributes) string {
+	if a == nil {
+		return ""
+	}
+	var decls []string
+	switch a.Alignment() {
+	case ruler.Right:
+		decls = append(decls, "text-align: right")
+	case ruler.Center:
+		decls = append(decls, "text-align: center")
+	case ruler.Justified:
+		decls = append(decls, "text-align: justify")
+	}
+	if a.Left != 0 {
+		decls = append(decls, fmt.Sprintf("margin-left: %smm", document.MM(a.Left)))
+	}
+	if a.First != a.Left {
+		decls = append(decls, fmt.Sprintf("text-indent: %smm", document.MM(a.First-a.Left)))
+	}
+	if a.Has(ruler.PageBreak) {
+		decls = append(decls, "break-before: page")
+	}
+	if len(decls) == 0 {
+		return ""
+	}
+	return fmt.Sprintf(` style="%s"`, strings.Join(decls, "; "))
+}
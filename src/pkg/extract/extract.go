@@ -0,0 +1,298 @@
+// Package extract turns a document into plain text annotated with its
+// structure: paragraphs and their format, links and link targets. The
+// result can be written as Markdown or HTML.
+package extract
+
+import (
+	"strings"
+
+	"odcread/pkg/commander"
+	"odcread/pkg/document"
+	"odcread/pkg/dom"
+	"odcread/pkg/fold"
+	"odcread/pkg/link"
+	"odcread/pkg/oberon"
+	"odcread/pkg/picture"
+	"odcread/pkg/ruler"
+	"odcread/pkg/store"
+	"odcread/pkg/tabview"
+	"odcread/pkg/textmodel"
+	"odcread/pkg/textview"
+	"odcread/pkg/visitor"
+)
+
+// Paragraph is a paragraph of the extracted text.
+type Paragraph struct {
+	Start, End int               // Byte offsets in Document.Text, without the separator
+	Attrs      *ruler.Attributes // Format, or nil for the default ruler
+	Heading    bool              // A section heading, e.g. the label of a tab
+}
+
+// Link is the text between an opening and a closing StdLinks.Link.
+type Link struct {
+	Start, End int    // Byte offsets in Document.Text
+	Command    string // E.g. "StdLinks.ShowTarget('intro')"
+	Close      oberon.Integer
+}
+
+// Href returns the URL the link leads to, or "" if its command does not
+// open a document, a target or a URL. Links to BlackBox documents get the
+// extension ext (e.g. ".html"), or ".odc" if ext is "".
+func (l Link) Href(ext string) string {
+	return href(l.Command, ext)
+}
+
+// Target is the text between an opening and a closing StdLinks.Target.
+type Target struct {
+	Start, End int // Byte offsets in Document.Text
+	Ident      string
+}
+
+// Command is the text following a DevCommanders commander, up to an end
+// view, the next commander or the end of the paragraph.
+type Command struct {
+	Start, End int    // Byte offsets in Document.Text
+	Text       string // The command with surrounding white space removed
+}
+
+// Image is a picture embedded in the text (see picture.Is).
+type Image struct {
+	Pos  int         // Byte offset in Document.Text
+	View store.Store // The picture view
+	Src  string      // Where the picture was written to, or "" if it was not
+}
+
+// Document is the extracted text of a document.
+type Document struct {
+	Text       string // Paragraphs separated by "\n"
+	Paragraphs []Paragraph
+	Links      []Link
+	Targets    []Target
+	Commands   []Command
+	Images     []Image
+	Page       *document.Page // Page setup, if the root is a StdDocument
+}
+
+// Options control how embedded views are extracted.
+type Options struct {
+	Commander string // Text that stands for a commander (e.g. "!"), or "" to drop commanders
+}
+
+// Extract returns the main text of root (see textview.MainText) with the
+// default options. Collapsed folds are extracted expanded.
+func Extract(root store.Store) *Document {
+	return ExtractOptions(root, Options{})
+}
+
+// ExtractOptions is like Extract, with the given options.
+func ExtractOptions(root store.Store, opts Options) *Document {
+	x := &extractor{doc: &Document{}, opts: opts, seenTabs: make(map[*tabview.View]bool)}
+	if d, ok := root.(*document.StdDocument); ok {
+		page := d.GetPage()
+		x.doc.Page = &page
+	}
+	x.content(root)
+	x.closeAll()
+	return x.doc
+}
+
+// extractor accumulates the text and its spans.
+type extractor struct {
+	doc      *Document
+	opts     Options
+	sb       strings.Builder
+	attrs    *ruler.Attributes
+	start    int  // Start of the current paragraph
+	heading  bool // The current paragraph is a heading
+	link     *Link
+	target   *Target
+	command  *Command
+	seenTabs map[*tabview.View]bool // Tab views already extracted
+}
+
+// content extracts the main text of s (see textview.MainText), or the
+// tabs of s if it is a tab view or shows one instead of a text.
+func (x *extractor) content(s store.Store) {
+	var found store.Store
+	visitor.Walk(s, func(a visitor.Ancestry) visitor.Action {
+		switch v := a.Store().(type) {
+		case *textmodel.StdTextModel:
+			if v != s {
+				return visitor.Continue
+			}
+			found = v
+		case *tabview.View:
+			found = v
+		case *textview.StdView:
+			if v.GetText() == nil {
+				return visitor.Continue
+			}
+			found = v.GetText()
+		default:
+			return visitor.Continue
+		}
+		return visitor.Stop
+	})
+	switch v := found.(type) {
+	case *tabview.View:
+		x.tabs(v)
+	case *textmodel.StdTextModel:
+		x.text(dom.Build(v))
+	}
+}
+
+// tabs extracts the tabs of a tab view as sections headed by their labels.
+// A tab view that was extracted before (e.g. one that a tab links back to)
+// is left out.
+func (x *extractor) tabs(tv *tabview.View) {
+	if x.seenTabs[tv] {
+		return
+	}
+	x.seenTabs[tv] = true
+	for _, t := range tv.GetTabs() {
+		if x.sb.Len() > x.start {
+			x.write("\n")
+		}
+		x.heading = true
+		x.write(t.Label + "\n")
+		if t.View != nil {
+			x.content(t.View)
+		}
+	}
+	if x.sb.Len() > x.start {
+		x.write("\n")
+	}
+}
+
+// text extracts the pieces of a text model node.
+func (x *extractor) text(n *dom.Node) {
+	skip := 0 // Nesting depth of folds whose visible text is skipped
+	for _, c := range n.Children {
+		switch c.Kind {
+		case dom.ShortPiece, dom.LongPiece:
+			if skip == 0 {
+				x.write(c.Text())
+			}
+		case dom.ViewPiece:
+			if len(c.Children) == 0 {
+				continue
+			}
+			v := c.Children[0]
+			if f, ok := v.Store.(*fold.Fold); ok {
+				skip = x.fold(f, v, skip)
+			} else if skip == 0 {
+				x.view(v)
+			}
+		}
+	}
+}
+
+// fold handles a fold view and returns the new skip depth. The hidden
+// text of a collapsed fold replaces the visible text up to its partner.
+func (x *extractor) fold(f *fold.Fold, v *dom.Node, skip int) int {
+	switch {
+	case f.GetHidden() == nil: // Closing fold
+		if skip > 0 {
+			skip--
+		}
+	case skip > 0:
+		skip++
+	case f.IsCollapsed() && len(v.Children) > 0:
+		x.text(v.Children[0])
+		skip = 1
+	}
+	return skip
+}
+
+// view handles an embedded view other than a fold.
+func (x *extractor) view(v *dom.Node) {
+	pos := x.sb.Len()
+	if picture.Is(v.Store) {
+		x.doc.Images = append(x.doc.Images, Image{Pos: pos, View: v.Store})
+		return
+	}
+	switch s := v.Store.(type) {
+	case *ruler.StdRuler:
+		if a := s.GetAttributes(); a != nil {
+			x.attrs = a
+		}
+	case *link.Link:
+		x.closeLink(pos)
+		if s.IsOpening() {
+			x.link = &Link{Start: pos, Command: s.GetCommand(), Close: s.GetClose()}
+		}
+	case *link.Target:
+		x.closeTarget(pos)
+		if s.IsOpening() {
+			x.target = &Target{Start: pos, Ident: s.GetIdent()}
+		}
+	case *tabview.View:
+		x.tabs(s)
+	case *commander.StdView:
+		x.closeCommand(pos)
+		x.write(x.opts.Commander)
+		x.command = &Command{Start: x.sb.Len()}
+	case *commander.StdEndView:
+		x.closeCommand(pos)
+	case store.InlineText:
+		x.write(s.InlineText())
+	}
+}
+
+// write appends text, ending paragraphs at line and paragraph separators.
+func (x *extractor) write(s string) {
+	for _, r := range s {
+		if r == '\n' || r == '\x0E' {
+			x.endParagraph()
+			x.sb.WriteByte('\n')
+			x.start = x.sb.Len()
+		} else {
+			x.sb.WriteRune(r)
+		}
+	}
+}
+
+func (x *extractor) endParagraph() {
+	x.closeCommand(x.sb.Len())
+	x.doc.Paragraphs = append(x.doc.Paragraphs, Paragraph{Start: x.start, End: x.sb.Len(), Attrs: x.attrs, Heading: x.heading})
+	x.heading = false
+}
+
+func (x *extractor) closeLink(pos int) {
+	if x.link != nil {
+		x.link.End = pos
+		x.doc.Links = append(x.doc.Links, *x.link)
+		x.link = nil
+	}
+}
+
+func (x *extractor) closeTarget(pos int) {
+	if x.target != nil {
+		x.target.End = pos
+		x.doc.Targets = append(x.doc.Targets, *x.target)
+		x.target = nil
+	}
+}
+
+func (x *extractor) closeCommand(pos int) {
+	if x.command != nil {
+		x.command.End = pos
+		x.command.Text = strings.TrimSpace(x.sb.String()[x.command.Start:pos])
+		if x.command.Text != "" {
+			x.doc.Commands = append(x.doc.Commands, *x.command)
+		}
+		x.command = nil
+	}
+}
+
+// closeAll ends the last paragraph and any unclosed link or target.
+func (x *extractor) closeAll() {
+	pos := x.sb.Len()
+	if pos > x.start || len(x.doc.Paragraphs) == 0 {
+		x.endParagraph()
+	}
+	x.closeLink(pos)
+	x.closeTarget(pos)
+	x.closeCommand(pos)
+	x.doc.Text = x.sb.String()
+}
@@ -0,0 +1,152 @@
+package extract_test
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"odcread/internal/testdoc"
+	"odcread/pkg/extract"
+	"odcread/pkg/reader"
+	"odcread/pkg/ruler"
+	_ "odcread/pkg/typeregister" // Import for side-effect (type registration)
+)
+
+func TestExtract_Links(t *testing.T) {
+	v := func(s []byte) testdoc.View { return testdoc.View{Store: s} }
+	doc := testdoc.TextModel(
+		v(testdoc.Ruler(0, 0, 0, 0)), "See ",
+		v(testdoc.Link("StdLinks.ShowTarget('intro')")), "intro", v(testdoc.Link("")),
+		", ", v(testdoc.Link("StdCmds.OpenBrowser('Obx/Docu/Sys-Map', 'Map')")), "map", v(testdoc.Link("")),
+		" and ", v(testdoc.Link("DevDebug.ShowLoadedModules")), "modules", v(testdoc.Link("")),
+		"\r", v(testdoc.Target("intro")), "Intro", v(testdoc.Target("")), " text",
+	)
+	s, err := reader.NewReader(bytes.NewReader(doc)).ReadStore()
+	if err != nil {
+		t.Fatalf("ReadStore failed: %v", err)
+	}
+
+	d := extract.Extract(s)
+	if d.Text != "See intro, map and modules\nIntro text" {
+		t.Fatalf("Unexpected text %q", d.Text)
+	}
+	if len(d.Paragraphs) != 2 || d.Paragraphs[0].Attrs.Alignment() != ruler.Center {
+		t.Fatalf("Unexpected paragraphs %+v", d.Paragraphs)
+	}
+	if len(d.Links) != 3 || d.Text[d.Links[1].Start:d.Links[1].End] != "map" {
+		t.Fatalf("Unexpected links %+v", d.Links)
+	}
+	if len(d.Targets) != 1 || d.Text[d.Targets[0].Start:d.Targets[0].End] != "Intro" {
+		t.Fatalf("Unexpected targets %+v", d.Targets)
+	}
+
+	var md bytes.Buffer
+	extract.WriteMarkdown(&md, d)
+	want := "See [intro](#intro), [map](Obx/Docu/Sys-Map.md) and modules\n\n<a id=\"intro\"></a>Intro text\n"
+	if md.String() != want {
+		t.Errorf("Expected Markdown %q, got %q", want, md.String())
+	}
+
+	var html bytes.Buffer
+	extract.WriteHTML(&html, "Test", d)
+	for _, frag := range []string{
+		`<p style="text-align: center">See <a href="#intro">intro</a>`,
+		`<a href="Obx/Docu/Sys-Map.html">map</a>`,
+		`<span class="command" title="DevDebug.ShowLoadedModules">modules</span>`,
+		`<p style="text-align: center"><a id="intro"></a>Intro text</p>`,
+	} {
+		if !strings.Contains(html.String(), frag) {
+			t.Errorf("Expected HTML to contain %q, got\n%s", frag, html.String())
+		}
+	}
+}
+
+func TestExtract_CollapsedFold(t *testing.T) {
+	fold := testdoc.View{Store: testdoc.Fold(true, "", testdoc.TextModel("expanded"))}
+	end := testdoc.View{Store: testdoc.Fold(true, "", nil)}
+	doc := testdoc.TextModel("a ", fold, "short", end, " b")
+	s, err := reader.NewReader(bytes.NewReader(doc)).ReadStore()
+	if err != nil {
+		t.Fatalf("ReadStore failed: %v", err)
+	}
+	if got := extract.Extract(s).Text; got != "a expanded b" {
+		t.Errorf("Expected %q, got %q", "a expanded b", got)
+	}
+}
+
+func TestExtract_Commanders(t *testing.T) {
+	cmd := testdoc.View{Store: testdoc.Commander()}
+	end := testdoc.View{Store: testdoc.CommanderEnd()}
+	doc := testdoc.TextModel(
+		"Compile: ", cmd, "DevCompiler.CompileThis ObxHello0 ", end, " then run ", cmd, "ObxHello0.Do\r",
+		cmd, " \rdone",
+	)
+	s, err := reader.NewReader(bytes.NewReader(doc)).ReadStore()
+	if err != nil {
+		t.Fatalf("ReadStore failed: %v", err)
+	}
+
+	if got := extract.Extract(s).Text; got != "Compile: DevCompiler.CompileThis ObxHello0  then run ObxHello0.Do\n \ndone" {
+		t.Errorf("Unexpected text without commanders %q", got)
+	}
+	d := extract.ExtractOptions(s, extract.Options{Commander: "!"})
+	if d.Text != "Compile: !DevCompiler.CompileThis ObxHello0  then run !ObxHello0.Do\n! \ndone" {
+		t.Errorf("Unexpected text with commanders %q", d.Text)
+	}
+	var cmds []string
+	for _, c := range d.Commands {
+		cmds = append(cmds, c.Text)
+	}
+	if got := strings.Join(cmds, "|"); got != "DevCompiler.CompileThis ObxHello0|ObxHello0.Do" {
+		t.Errorf("Unexpected commands %q", got)
+	}
+}
+
+func TestExtract_Tabs(t *testing.T) {
+	tabs := testdoc.TabView(
+		testdoc.Tab{Label: "General", View: testdoc.StdView(testdoc.TextModel("First tab"), 0, false)},
+		testdoc.Tab{Label: "Options", View: testdoc.StdView(testdoc.TextModel("Second\rtab"), 0, false)},
+	)
+	doc := testdoc.TextModel("Before", testdoc.View{Store: tabs}, "After")
+	s, err := reader.NewReader(bytes.NewReader(doc)).ReadStore()
+	if err != nil {
+		t.Fatalf("ReadStore failed: %v", err)
+	}
+
+	d := extract.Extract(s)
+	if d.Text != "Before\nGeneral\nFirst tab\nOptions\nSecond\ntab\nAfter" {
+		t.Fatalf("Unexpected text %q", d.Text)
+	}
+	var md bytes.Buffer
+	extract.WriteMarkdown(&md, d)
+	want := "Before\n\n### General\n\nFirst tab\n\n### Options\n\nSecond\n\ntab\n\nAfter\n"
+	if md.String() != want {
+		t.Errorf("Expected Markdown %q, got %q", want, md.String())
+	}
+}
+
+func TestExtract_TargetAtParagraphEnd(t *testing.T) {
+	v := func(s []byte) testdoc.View { return testdoc.View{Store: s} }
+	doc := testdoc.TextModel(
+		v(testdoc.Link("StdLinks.ShowTarget('end')")), "end", v(testdoc.Link("")),
+		"\rFirst", v(testdoc.Target("end")), v(testdoc.Target("")), "\rSecond",
+	)
+	s, err := reader.NewReader(bytes.NewReader(doc)).ReadStore()
+	if err != nil {
+		t.Fatalf("ReadStore failed: %v", err)
+	}
+
+	d := extract.Extract(s)
+	if d.Text != "end\nFirst\nSecond" || len(d.Targets) != 1 || d.Targets[0].Start != d.Paragraphs[1].End {
+		t.Fatalf("Expected a target at the end of the second paragraph, got %q, %+v, %+v", d.Text, d.Targets, d.Paragraphs)
+	}
+
+	var html bytes.Buffer
+	extract.WriteHTML(&html, "Test", d)
+	if got := strings.Count(html.String(), `<a id="end"></a>`); got != 1 {
+		t.Errorf("Expected one anchor, got %d in\n%s", got, html.String())
+	}
+	if !strings.Contains(html.String(), `<p>First<a id="end"></a></p>`) {
+		t.Errorf("Expected the anchor at the end of its paragraph, got\n%s", html.String())
+	}
+}
@@ -0,0 +1,69 @@
+package extract
+
+import (
+	"path"
+	"strings"
+)
+
+// href maps a link command to a URL. Commands may be sequences separated
+// by ";"; the first one that navigates wins.
+func href(cmd, ext string) string {
+	if ext == "" {
+		ext = ".odc"
+	}
+	for _, c := range strings.Split(cmd, ";") {
+		c = strings.TrimSpace(c)
+		name, arg := c, ""
+		if i := strings.IndexByte(c, '('); i >= 0 {
+			name, arg = strings.TrimSpace(c[:i]), firstArg(c[i+1:])
+		}
+		switch {
+		case arg == "":
+		case isURL(arg):
+			return arg
+		case name == "StdLinks.ShowTarget":
+			return "#" + arg
+		case name == "StdCmds.OpenBrowser", name == "StdCmds.OpenDoc",
+			name == "StdCmds.OpenAuxDialog", name == "StdCmds.OpenToolDialog":
+			return docPath(arg, ext)
+		}
+	}
+	return ""
+}
+
+// firstArg returns the first quoted argument in the argument list s.
+func firstArg(s string) string {
+	i := strings.IndexAny(s, `'"`)
+	if i < 0 {
+		return ""
+	}
+	j := strings.IndexByte(s[i+1:], s[i])
+	if j < 0 {
+		return ""
+	}
+	return s[i+1 : i+1+j]
+}
+
+// isURL reports whether s is an absolute URL.
+func isURL(s string) bool {
+	for _, scheme := range []string{"http://", "https://", "mailto:", "ftp://"} {
+		if strings.HasPrefix(strings.ToLower(s), scheme) {
+			return true
+		}
+	}
+	return false
+}
+
+// docPath converts a BlackBox document location such as "Obx/Docu/Sys-Map"
+// into a relative path with extension ext.
+func docPath(loc, ext string) string {
+	loc = strings.ReplaceAll(loc, "\\", "/")
+	switch strings.ToLower(path.Ext(loc)) {
+	case ".odc":
+		loc = strings.TrimSuffix(loc, path.Ext(loc))
+	case "":
+	default:
+		return loc
+	}
+	return loc + ext
+}
@@ -113,23 +113,6 @@ func (f *Fold) String() string {
 	return fmt.Sprintf("Fold{id: %d, collapsed: %v, label: %q}", f.GetID(), f.collapsed, labelStr)
 }
 
-// Accept implements the visitor pattern for Fold.
-func (f *Fold) Accept(visitor store.Visitor) {
-	// Check if this fold has already been visited (prevents cycles from LINK/NEWLINK)
-	if !visitor.ShouldVisit(f) {
-		return
-	}
-
-	visitor.FoldLeft(f.collapsed)
-
-	// Visit the hidden part
-	if f.hidden != nil {
-		f.hidden.Accept(visitor)
-	}
-
-	visitor.FoldRight()
-}
-
 // IsCollapsed returns whether the fold is collapsed.
 func (f *Fold) IsCollapsed() bool {
 	return f.collapsed
@@ -0,0 +1,36 @@
+// Package font provides the font description that views such as controls
+// and headers store with them.
+package font
+
+import (
+	"odcread/pkg/oberon"
+	"odcread/pkg/store"
+)
+
+// Font is a font as stored by views.
+type Font struct {
+	Typeface string
+	Size     oberon.Integer // In universal units
+	Style    oberon.Set
+	Weight   oberon.Integer
+}
+
+// Read reads a font.
+// Format:
+//
+//	typeface (string), size (int), style (set), weight (int)
+func Read(reader store.Reader) (Font, error) {
+	var f Font
+	var err error
+	if f.Typeface, err = reader.ReadString(); err != nil {
+		return f, err
+	}
+	if f.Size, err = reader.ReadInt(); err != nil {
+		return f, err
+	}
+	if f.Style, err = reader.ReadSet(); err != nil {
+		return f, err
+	}
+	f.Weight, err = reader.ReadInt()
+	return f, err
+}
@@ -0,0 +1,67 @@
+package form
+
+import (
+	"strings"
+
+	"odcread/pkg/control"
+	"odcread/pkg/document"
+	"odcread/pkg/store"
+	"odcread/pkg/visitor"
+)
+
+// Dialog describes the layout of a form, e.g. for auditing or translating
+// dialogs. It marshals to JSON.
+type Dialog struct {
+	Controls []Control `json:"controls"`
+}
+
+// Control describes a view of a form. Views that are not controls have
+// only a type and bounds.
+type Control struct {
+	Type     string        `json:"type"` // e.g. "PushButton", or the type name of other views
+	Bounds   document.Rect `json:"bounds"`
+	Label    string        `json:"label,omitempty"`
+	Link     string        `json:"link,omitempty"`
+	Guard    string        `json:"guard,omitempty"`
+	Notifier string        `json:"notifier,omitempty"`
+}
+
+// Dialogs returns the layouts of the form models in the tree rooted at
+// root, in document order.
+func Dialogs(root store.Store) []Dialog {
+	var list []Dialog
+	visitor.Walk(root, func(a visitor.Ancestry) visitor.Action {
+		if m, ok := a.Store().(*StdModel); ok {
+			list = append(list, NewDialog(m))
+		}
+		return visitor.Continue
+	})
+	return list
+}
+
+// typeName returns the type name of s without the trailing "^"; for aliens,
+// whose GetTypeName is their base type, it is the name they were stored with.
+func typeName(s store.Store) string {
+	name := s.GetTypeName()
+	if path := s.GetTypePath(); len(path) > 0 {
+		name = path[0]
+	}
+	return strings.TrimSuffix(name, "^")
+}
+
+// NewDialog returns the layout of a form model.
+func NewDialog(m *StdModel) Dialog {
+	d := Dialog{Controls: make([]Control, 0, len(m.items))}
+	for _, it := range m.items {
+		c := Control{Type: typeName(it.View), Bounds: it.Bounds}
+		if ctl, ok := it.View.(*control.Control); ok {
+			c.Type = ctl.Kind()
+			c.Label = ctl.GetLabel()
+			c.Link = ctl.GetLink()
+			c.Guard = ctl.GetGuard()
+			c.Notifier = ctl.GetNotifier()
+		}
+		d.Controls = append(d.Controls, c)
+	}
+	return d
+}
@@ -0,0 +1,157 @@
+// Package form provides form models and views (FormModels, FormViews),
+// which lay out views such as controls at fixed positions, e.g. dialogs.
+package form
+
+import (
+	"fmt"
+
+	"odcread/pkg/container"
+	"odcread/pkg/document"
+	"odcread/pkg/oberon"
+	"odcread/pkg/store"
+)
+
+const (
+	TypeNameStdModel = "FormModels.StdModel^"
+	TypeNameStdView  = "FormViews.StdView^"
+)
+
+// Item is a view placed in a form.
+type Item struct {
+	View   store.Store
+	Bounds document.Rect
+}
+
+// StdModel is the standard form model (FormModels.StdModel).
+type StdModel struct {
+	store.ContainerModel
+	items []Item // In z-order, back to front
+}
+
+// NewStdModel creates a new StdModel instance.
+func NewStdModel(id oberon.Integer) *StdModel {
+	return &StdModel{
+		ContainerModel: *store.NewContainerModel(id),
+	}
+}
+
+// GetTypeName returns the type name for StdModel.
+func (m *StdModel) GetTypeName() string {
+	return TypeNameStdModel
+}
+
+// Internalize reads StdModel data from the reader.
+// Format (FormModels.StdModel.Internalize):
+//
+//	version (0)
+//	for each view: view (store), l, t, r, b (int)
+//	nil store
+func (m *StdModel) Internalize(reader store.Reader) error {
+	if err := m.ContainerModel.Internalize(reader); err != nil {
+		return err
+	}
+	if _, err := reader.ReadVersion(0, 0); err != nil {
+		return err
+	}
+
+	for {
+		v, err := reader.ReadStore()
+		if err != nil {
+			return fmt.Errorf("failed to read form view %d: %w", len(m.items)+1, err)
+		}
+		if v == nil {
+			return nil
+		}
+		item := Item{View: v}
+		for _, p := range []*oberon.Integer{&item.Bounds.Left, &item.Bounds.Top, &item.Bounds.Right, &item.Bounds.Bottom} {
+			if *p, err = reader.ReadInt(); err != nil {
+				return fmt.Errorf("failed to read bounds of form view %d: %w", len(m.items)+1, err)
+			}
+		}
+		m.items = append(m.items, item)
+	}
+}
+
+// String returns a string representation of the StdModel.
+func (m *StdModel) String() string {
+	return fmt.Sprintf("FormModel{id: %d, views: %d}", m.GetID(), len(m.items))
+}
+
+// GetItems returns the views of the form with their bounds, back to front.
+func (m *StdModel) GetItems() []Item {
+	return m.items
+}
+
+// Children returns the views of the form.
+func (m *StdModel) Children() []store.Store {
+	list := make([]store.Store, len(m.items))
+	for i, it := range m.items {
+		list[i] = it.View
+	}
+	return list
+}
+
+// StdView is the standard form view (FormViews.StdView).
+type StdView struct {
+	container.View
+	background oberon.Integer // Ports.Color
+	grid       oberon.Integer // Grid spacing, in universal units
+	gridFactor oberon.Integer // Grid lines are drawn every gridFactor grid points
+}
+
+// NewStdView creates a new StdView instance.
+func NewStdView(id oberon.Integer) *StdView {
+	return &StdView{
+		View: *container.NewView(id),
+	}
+}
+
+// GetTypeName returns the type name for StdView.
+func (v *StdView) GetTypeName() string {
+	return TypeNameStdView
+}
+
+// Internalize reads StdView data from the reader.
+// Format (FormViews.StdView.Internalize2):
+//
+//	version (0..1)
+//	grid, gridFactor (int)
+//	background (int), if version 1
+func (v *StdView) Internalize(reader store.Reader) error {
+	if err := v.View.Internalize(reader); err != nil {
+		return err
+	}
+	version, err := reader.ReadVersion(0, 1)
+	if err != nil {
+		return err
+	}
+
+	if v.grid, err = reader.ReadInt(); err != nil {
+		return fmt.Errorf("failed to read form grid: %w", err)
+	}
+	if v.gridFactor, err = reader.ReadInt(); err != nil {
+		return fmt.Errorf("failed to read form grid factor: %w", err)
+	}
+	if version > 0 {
+		if v.background, err = reader.ReadInt(); err != nil {
+			return fmt.Errorf("failed to read form background: %w", err)
+		}
+	}
+	return nil
+}
+
+// String returns a string representation of the StdView.
+func (v *StdView) String() string {
+	return fmt.Sprintf("FormView{id: %d, grid: %d}", v.GetID(), v.grid)
+}
+
+// GetGrid returns the grid spacing and the number of grid points between
+// drawn grid lines.
+func (v *StdView) GetGrid() (grid, factor oberon.Integer) {
+	return v.grid, v.gridFactor
+}
+
+// GetBackground returns the background colour (Ports.Color).
+func (v *StdView) GetBackground() oberon.Integer {
+	return v.background
+}
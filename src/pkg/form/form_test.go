@@ -0,0 +1,49 @@
+package form_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+
+	"odcread/internal/testdoc"
+	"odcread/pkg/form"
+	"odcread/pkg/reader"
+	_ "odcread/pkg/typeregister" // Import for side-effect (type registration)
+)
+
+func TestDialogs(t *testing.T) {
+	doc := testdoc.Form(
+		testdoc.FormItem{
+			View:   testdoc.Control("Field", "Dialog.name", "", "", "Dialog.NameChanged"),
+			Bounds: [4]int32{10, 20, 110, 40},
+		},
+		testdoc.FormItem{
+			View:   testdoc.Control("PushButton", "Dialog.Ok", "&Öffnen", "Dialog.OkGuard", ""),
+			Bounds: [4]int32{10, 50, 60, 70},
+		},
+		testdoc.FormItem{View: testdoc.Opaque("StdClocks.StdViewDesc"), Bounds: [4]int32{0, 0, 5, 5}},
+	)
+	s, err := reader.NewReader(bytes.NewReader(doc)).ReadStore()
+	if err != nil {
+		t.Fatalf("ReadStore failed: %v", err)
+	}
+
+	dialogs := form.Dialogs(s)
+	if len(dialogs) != 1 || len(dialogs[0].Controls) != 3 {
+		t.Fatalf("Unexpected dialogs %+v", dialogs)
+	}
+	var out bytes.Buffer
+	enc := json.NewEncoder(&out)
+	enc.SetEscapeHTML(false)
+	if err := enc.Encode(dialogs[0].Controls[:2]); err != nil {
+		t.Fatalf("Encode failed: %v", err)
+	}
+	want := `[{"type":"Field","bounds":{"left":10,"top":20,"right":110,"bottom":40},"link":"Dialog.name","notifier":"Dialog.NameChanged"},` +
+		`{"type":"PushButton","bounds":{"left":10,"top":50,"right":60,"bottom":70},"label":"&Öffnen","link":"Dialog.Ok","guard":"Dialog.OkGuard"}]` + "\n"
+	if out.String() != want {
+		t.Errorf("Expected\n%s\ngot\n%s", want, out.String())
+	}
+	if got := dialogs[0].Controls[2].Bounds.Right; got != 5 {
+		t.Errorf("Expected the third view to end at 5, got %d", got)
+	}
+}
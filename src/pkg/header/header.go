@@ -0,0 +1,137 @@
+// Package header provides header views, which show a banner with the
+// page number at the top of printed pages. As the banners belong to the
+// printed page rather than to the text, extractors leave them out; they
+// are available from GetHead and GetTail.
+package header
+
+import (
+	"fmt"
+
+	"odcread/pkg/fold"
+	"odcread/pkg/font"
+	"odcread/pkg/oberon"
+	"odcread/pkg/store"
+)
+
+const TypeNameHeader = "StdHeaders.View^"
+
+// Banner is the text of a header line: Left is left aligned and Right is
+// right aligned. Both may contain the page number placeholder "&p".
+type Banner struct {
+	Left, Right string
+	Gap         oberon.Integer // Space between the banner and the page, in universal units
+}
+
+// NumberInfo controls the page numbering.
+type NumberInfo struct {
+	New   bool           // Restart numbering at First
+	First oberon.Integer // Number of the first page
+}
+
+// Header is a header view (StdHeaders.View).
+type Header struct {
+	fold.View
+	alternate bool // Swap Left and Right on even pages
+	number    NumberInfo
+	head      Banner
+	tail      Banner
+	font      font.Font
+}
+
+// NewHeader creates a new Header instance.
+func NewHeader(id oberon.Integer) *Header {
+	return &Header{
+		View: *fold.NewView(id),
+	}
+}
+
+// GetTypeName returns the type name for Header.
+func (h *Header) GetTypeName() string {
+	return TypeNameHeader
+}
+
+// Internalize reads Header data from the reader.
+// Format (StdHeaders.View.Internalize):
+//
+//	version (1)
+//	alternate (bool), number.new (bool), number.first (int)
+//	head.left, head.right (string), head.gap (int)
+//	tail.left, tail.right (string), tail.gap (int)
+//	typeface (string), size (int), style (set), weight (int)
+func (h *Header) Internalize(reader store.Reader) error {
+	if err := h.View.Internalize(reader); err != nil {
+		return err
+	}
+	// Version 0 headers, which had no page numbering options, are read as aliens
+	if _, err := reader.ReadVersion(1, 1); err != nil {
+		return err
+	}
+
+	alternate, err := reader.ReadBool()
+	if err != nil {
+		return fmt.Errorf("failed to read header alternate flag: %w", err)
+	}
+	h.alternate = alternate
+	isNew, err := reader.ReadBool()
+	if err != nil {
+		return fmt.Errorf("failed to read header numbering: %w", err)
+	}
+	h.number.New = isNew
+	if h.number.First, err = reader.ReadInt(); err != nil {
+		return fmt.Errorf("failed to read header first page number: %w", err)
+	}
+	if err := readBanner(reader, &h.head); err != nil {
+		return fmt.Errorf("failed to read header head: %w", err)
+	}
+	if err := readBanner(reader, &h.tail); err != nil {
+		return fmt.Errorf("failed to read header tail: %w", err)
+	}
+	if h.font, err = font.Read(reader); err != nil {
+		return fmt.Errorf("failed to read header font: %w", err)
+	}
+	return nil
+}
+
+func readBanner(reader store.Reader, b *Banner) error {
+	var err error
+	if b.Left, err = reader.ReadString(); err != nil {
+		return err
+	}
+	if b.Right, err = reader.ReadString(); err != nil {
+		return err
+	}
+	b.Gap, err = reader.ReadInt()
+	return err
+}
+
+// String returns a string representation of the Header.
+func (h *Header) String() string {
+	return fmt.Sprintf("Header{id: %d, head: %q/%q, tail: %q/%q}",
+		h.GetID(), h.head.Left, h.head.Right, h.tail.Left, h.tail.Right)
+}
+
+// GetHead returns the banner shown above the page text.
+func (h *Header) GetHead() Banner {
+	return h.head
+}
+
+// GetTail returns the banner shown below the page text.
+func (h *Header) GetTail() Banner {
+	return h.tail
+}
+
+// GetNumbering returns the page numbering options.
+func (h *Header) GetNumbering() NumberInfo {
+	return h.number
+}
+
+// GetFont returns the font the header is shown in.
+func (h *Header) GetFont() font.Font {
+	return h.font
+}
+
+// Alternate reports whether the left and right banner texts are swapped
+// on even pages.
+func (h *Header) Alternate() bool {
+	return h.alternate
+}
@@ -0,0 +1,48 @@
+package header_test
+
+import (
+	"testing"
+
+	"odcread/internal/testdoc"
+	"odcread/internal/testdoc/load"
+	"odcread/pkg/extract"
+	"odcread/pkg/header"
+	_ "odcread/pkg/typeregister" // Import for side-effect (type registration)
+)
+
+func TestHeader(t *testing.T) {
+	view := testdoc.HeaderView(true, 3, [2]string{"Manual", "page &p"}, [2]string{"", "draft"})
+	doc := testdoc.TextModel(testdoc.View{Store: view}, "Body")
+	s := load.Store(t, doc)
+
+	h, ok := load.View(t, s, 0).(*header.Header)
+	if !ok {
+		t.Fatalf("Expected a header, got %v", s)
+	}
+	if head := h.GetHead(); head.Left != "Manual" || head.Right != "page &p" || head.Gap != 2*36000 {
+		t.Errorf("Unexpected head %+v", head)
+	}
+	if tail := h.GetTail(); tail.Left != "" || tail.Right != "draft" {
+		t.Errorf("Unexpected tail %+v", tail)
+	}
+	if n := h.GetNumbering(); !n.New || n.First != 3 || !h.Alternate() {
+		t.Errorf("Unexpected numbering %+v, alternate %v", n, h.Alternate())
+	}
+	if f := h.GetFont(); f.Typeface != "Arial" || f.Size != 10*12700 || f.Weight != 400 {
+		t.Errorf("Unexpected font %+v", f)
+	}
+
+	// The banners belong to the printed page, not to the text
+	if got := extract.Extract(s).Text; got != "Body" {
+		t.Errorf("Expected %q, got %q", "Body", got)
+	}
+}
+
+// TestHeader_Corpus checks the headers of the documents written by BlackBox.
+func TestHeader_Corpus(t *testing.T) {
+	for _, s := range load.Corpus(t, header.TypeNameHeader) {
+		if f := s.(*header.Header).GetFont(); f.Typeface == "" || f.Size <= 0 {
+			t.Errorf("Unexpected font %+v", f)
+		}
+	}
+}
@@ -0,0 +1,182 @@
+// Package link provides hyperlink views and their targets.
+//
+// Links come in pairs around the link text: an opening link holding the
+// command that is executed when the text is clicked, and a closing link.
+// Targets likewise enclose the text that StdLinks.ShowTarget scrolls to.
+package link
+
+import (
+	"fmt"
+	"strings"
+
+	"odcread/pkg/fold"
+	"odcread/pkg/oberon"
+	"odcread/pkg/store"
+)
+
+const (
+	TypeNameLink   = "StdLinks.Link^"
+	TypeNameTarget = "StdLinks.Target^"
+)
+
+// When the window containing a link is closed after following it (Link.close)
+const (
+	Always      = 0
+	IfShiftDown = 1
+	Never       = 2
+)
+
+// Link is an opening or closing hyperlink (StdLinks.Link).
+type Link struct {
+	fold.View
+	leftSide bool
+	cmd      string
+	close    oberon.Integer
+}
+
+// NewLink creates a new Link instance.
+func NewLink(id oberon.Integer) *Link {
+	return &Link{
+		View: *fold.NewView(id),
+	}
+}
+
+// GetTypeName returns the type name for Link.
+func (l *Link) GetTypeName() string {
+	return TypeNameLink
+}
+
+// Internalize reads Link data from the reader.
+// Format (StdLinks.Link.Internalize):
+//
+//	version (0..1)
+//	leftSide (bool)
+//	len (int): length of the command, 0 for a closing link
+//	command (xstring), if len > 0
+//	close (int), if version 1 and len > 0
+func (l *Link) Internalize(reader store.Reader) error {
+	if err := l.View.Internalize(reader); err != nil {
+		return err
+	}
+	version, err := reader.ReadVersion(0, 1)
+	if err != nil {
+		return err
+	}
+	if l.cmd, err = readText(reader); err != nil {
+		return fmt.Errorf("failed to read link command: %w", err)
+	}
+
+	l.leftSide = l.cmd != ""
+	switch {
+	case !l.leftSide:
+	case version == 1:
+		if l.close, err = reader.ReadInt(); err != nil {
+			return fmt.Errorf("failed to read link close mode: %w", err)
+		}
+	case strings.HasPrefix(l.cmd, "StdLinks.ShowTarget"):
+		l.close = Never
+	default:
+		l.close = IfShiftDown
+	}
+	return nil
+}
+
+// String returns a string representation of the Link.
+func (l *Link) String() string {
+	if !l.leftSide {
+		return fmt.Sprintf("Link{id: %d, close}", l.GetID())
+	}
+	return fmt.Sprintf("Link{id: %d, cmd: %q}", l.GetID(), l.cmd)
+}
+
+// IsOpening returns whether this is the link before the link text.
+func (l *Link) IsOpening() bool {
+	return l.leftSide
+}
+
+// GetCommand returns the command of an opening link, e.g.
+// "StdLinks.ShowTarget('intro')", or "" for a closing link.
+func (l *Link) GetCommand() string {
+	return l.cmd
+}
+
+// GetClose returns when the link's window is closed after following it:
+// Always, IfShiftDown or Never.
+func (l *Link) GetClose() oberon.Integer {
+	return l.close
+}
+
+// Target is an opening or closing anchor (StdLinks.Target).
+type Target struct {
+	fold.View
+	leftSide bool
+	ident    string
+}
+
+// NewTarget creates a new Target instance.
+func NewTarget(id oberon.Integer) *Target {
+	return &Target{
+		View: *fold.NewView(id),
+	}
+}
+
+// GetTypeName returns the type name for Target.
+func (t *Target) GetTypeName() string {
+	return TypeNameTarget
+}
+
+// Internalize reads Target data from the reader.
+// Format (StdLinks.Target.Internalize):
+//
+//	version (0)
+//	leftSide (bool)
+//	len (int): length of the identifier, 0 for a closing target
+//	identifier (xstring), if len > 0
+func (t *Target) Internalize(reader store.Reader) error {
+	if err := t.View.Internalize(reader); err != nil {
+		return err
+	}
+	if _, err := reader.ReadVersion(0, 0); err != nil {
+		return err
+	}
+	var err error
+	if t.ident, err = readText(reader); err != nil {
+		return fmt.Errorf("failed to read target identifier: %w", err)
+	}
+	t.leftSide = t.ident != ""
+	return nil
+}
+
+// String returns a string representation of the Target.
+func (t *Target) String() string {
+	if !t.leftSide {
+		return fmt.Sprintf("Target{id: %d, close}", t.GetID())
+	}
+	return fmt.Sprintf("Target{id: %d, ident: %q}", t.GetID(), t.ident)
+}
+
+// IsOpening returns whether this is the target before the anchor text.
+func (t *Target) IsOpening() bool {
+	return t.leftSide
+}
+
+// GetIdent returns the identifier of an opening target, or "".
+func (t *Target) GetIdent() string {
+	return t.ident
+}
+
+// readText reads the leftSide flag (which is recomputed from the text)
+// and a length-prefixed string, as written by StdLinks.
+func readText(reader store.Reader) (string, error) {
+	if _, err := reader.ReadBool(); err != nil {
+		return "", err
+	}
+	n, err := reader.ReadInt()
+	if err != nil {
+		return "", err
+	}
+	if n == 0 {
+		return "", nil
+	}
+	return reader.ReadXString()
+}
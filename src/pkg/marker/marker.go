@@ -0,0 +1,98 @@
+// Package marker provides error markers (DevMarkers), which the compiler
+// inserts into a source text at the position of each error.
+package marker
+
+import (
+	"fmt"
+
+	"odcread/pkg/fold"
+	"odcread/pkg/oberon"
+	"odcread/pkg/store"
+)
+
+const TypeNameStdView = "DevMarkers.StdView^"
+
+// Marker modes.
+const (
+	Undefined = 0
+	Mark      = 1 // Shown as a marker
+	Message   = 2 // Expanded to show the error message
+)
+
+// StdView is an error marker (DevMarkers.StdView).
+type StdView struct {
+	fold.View
+	mode oberon.Integer
+	err  oberon.Integer // Compiler error number
+	msg  string
+}
+
+// NewStdView creates a new StdView instance.
+func NewStdView(id oberon.Integer) *StdView {
+	return &StdView{
+		View: *fold.NewView(id),
+	}
+}
+
+// GetTypeName returns the type name for StdView.
+func (v *StdView) GetTypeName() string {
+	return TypeNameStdView
+}
+
+// Internalize reads StdView data from the reader.
+// Format (DevMarkers.StdView.Internalize):
+//
+//	version (0)
+//	mode (int)
+//	error number (int)
+//	message (string)
+func (v *StdView) Internalize(reader store.Reader) error {
+	if err := v.View.Internalize(reader); err != nil {
+		return err
+	}
+	if _, err := reader.ReadVersion(0, 0); err != nil {
+		return err
+	}
+
+	var err error
+	if v.mode, err = reader.ReadInt(); err != nil {
+		return fmt.Errorf("failed to read marker mode: %w", err)
+	}
+	if v.err, err = reader.ReadInt(); err != nil {
+		return fmt.Errorf("failed to read marker error number: %w", err)
+	}
+	if v.msg, err = reader.ReadString(); err != nil {
+		return fmt.Errorf("failed to read marker message: %w", err)
+	}
+	return nil
+}
+
+// String returns a string representation of the StdView.
+func (v *StdView) String() string {
+	return fmt.Sprintf("Marker{id: %d, err: %d, msg: %q}", v.GetID(), v.err, v.msg)
+}
+
+// GetMode returns the marker's mode, e.g. Mark.
+func (v *StdView) GetMode() oberon.Integer {
+	return v.mode
+}
+
+// GetError returns the compiler error number.
+func (v *StdView) GetError() oberon.Integer {
+	return v.err
+}
+
+// GetMessage returns the error message.
+func (v *StdView) GetMessage() string {
+	return v.msg
+}
+
+// InlineText returns the error number and message in brackets, e.g.
+// "[error 2: undeclared identifier]", so that errors stay visible in
+// extracted sources.
+func (v *StdView) InlineText() string {
+	if v.msg == "" {
+		return fmt.Sprintf("[error %d]", v.err)
+	}
+	return fmt.Sprintf("[error %d: %s]", v.err, v.msg)
+}
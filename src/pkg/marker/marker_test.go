@@ -0,0 +1,46 @@
+package marker_test
+
+import (
+	"strings"
+	"testing"
+
+	"odcread/internal/odc"
+	"odcread/internal/testdoc"
+	"odcread/internal/testdoc/load"
+	"odcread/pkg/extract"
+	"odcread/pkg/marker"
+	_ "odcread/pkg/typeregister" // Import for side-effect (type registration)
+)
+
+func TestMarker(t *testing.T) {
+	doc := testdoc.TextModel("x := ", testdoc.View{Store: testdoc.Marker(marker.Message, 0, "undeclared identifier")}, "y;",
+		testdoc.View{Store: testdoc.Marker(marker.Mark, 2, "")})
+	s := load.Store(t, doc)
+
+	m, ok := load.View(t, s, 1).(*marker.StdView)
+	if !ok {
+		t.Fatalf("Expected a marker, got %v", s)
+	}
+	if m.GetMode() != marker.Message || m.GetError() != 0 || m.GetMessage() != "undeclared identifier" {
+		t.Errorf("Unexpected marker %s", m)
+	}
+
+	want := "x := [error 0: undeclared identifier]y;[error 2]"
+	if got := extract.Extract(s).Text; got != want {
+		t.Errorf("Expected %q, got %q", want, got)
+	}
+	var sb strings.Builder
+	odc.NewMyVisitor(&sb).Visit(s)
+	if !strings.Contains(sb.String(), want) {
+		t.Errorf("Expected the text output to contain %q, got %q", want, sb.String())
+	}
+}
+
+// TestMarker_Corpus checks the error markers of the documents written by BlackBox.
+func TestMarker_Corpus(t *testing.T) {
+	for _, s := range load.Corpus(t, marker.TypeNameStdView) {
+		if m := s.(*marker.StdView); m.GetMode() < marker.Undefined || m.GetMode() > marker.Message {
+			t.Errorf("Unexpected marker %s", m)
+		}
+	}
+}
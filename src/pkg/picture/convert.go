@@ -0,0 +1,105 @@
+package picture
+
+import (
+	"bytes"
+	"encoding/binary"
+	"image"
+	"image/png"
+)
+
+// Signatures of the formats Convert recognizes.
+const (
+	emfRecord    = 1          // EMR_HEADER, the first record of an enhanced metafile
+	emfSignature = " EMF"     // At offset 40 of the EMR_HEADER
+	wmfPlaceable = 0x9AC6CDD7 // Aldus placeable metafile header
+	fileHeader   = 14         // Size of the BITMAPFILEHEADER of .bmp files
+)
+
+// Convert turns picture data into the contents of a file and returns them
+// with the file's extension:
+//   - device-independent bitmaps and .bmp files become ".png";
+//   - bitmaps that cannot be decoded (e.g. compressed ones) become ".bmp";
+//   - enhanced metafiles and Windows metafiles are returned as they are,
+//     as ".emf" and ".wmf";
+//   - anything else is returned as it is, as ".bin".
+func Convert(data []byte) (out []byte, ext string) {
+	switch {
+	case isBMP(data):
+		off := int(binary.LittleEndian.Uint32(data[10:])) - fileHeader
+		if img, err := decodeDIB(data[fileHeader:], off); err == nil {
+			if out, err := encodePNG(img); err == nil {
+				return out, ".png"
+			}
+		}
+		return data, ".bmp"
+	case isDIB(data):
+		if img, err := decodeDIB(data, -1); err == nil {
+			if out, err := encodePNG(img); err == nil {
+				return out, ".png"
+			}
+		}
+		return bmpFile(data), ".bmp"
+	case isEMF(data):
+		return data, ".emf"
+	case isWMF(data):
+		return data, ".wmf"
+	}
+	return data, ".bin"
+}
+
+// locate returns the picture data in b, which starts with unknown fields,
+// or nil if it contains no picture in a recognized format.
+func locate(b []byte) []byte {
+	for i := 0; i+4 <= len(b); i++ {
+		if d := b[i:]; isBMP(d) || isDIB(d) || isEMF(d) || isWMF(d) {
+			return d
+		}
+	}
+	return nil
+}
+
+func isBMP(b []byte) bool {
+	return len(b) > fileHeader && b[0] == 'B' && b[1] == 'M' && isDIB(b[fileHeader:])
+}
+
+// isDIB reports whether b starts with a plausible bitmap header.
+func isDIB(b []byte) bool {
+	h, err := parseHeader(b)
+	return err == nil && h.planes == 1
+}
+
+func isEMF(b []byte) bool {
+	return len(b) >= 44 && binary.LittleEndian.Uint32(b) == emfRecord && string(b[40:44]) == emfSignature
+}
+
+func isWMF(b []byte) bool {
+	if len(b) >= 4 && binary.LittleEndian.Uint32(b) == wmfPlaceable {
+		return true
+	}
+	// METAHEADER: type 1 (memory) or 2 (disk), header size 9 words, version 0x100 or 0x300
+	if len(b) < 18 {
+		return false
+	}
+	typ, size, version := binary.LittleEndian.Uint16(b), binary.LittleEndian.Uint16(b[2:]), binary.LittleEndian.Uint16(b[4:])
+	return (typ == 1 || typ == 2) && size == 9 && (version == 0x100 || version == 0x300)
+}
+
+// bmpFile returns a .bmp file holding the packed bitmap dib.
+func bmpFile(dib []byte) []byte {
+	h, _ := parseHeader(dib)
+	var buf bytes.Buffer
+	buf.WriteString("BM")
+	binary.Write(&buf, binary.LittleEndian, uint32(fileHeader+len(dib)))
+	binary.Write(&buf, binary.LittleEndian, uint32(0))
+	binary.Write(&buf, binary.LittleEndian, uint32(fileHeader+h.bitsOffset()))
+	buf.Write(dib)
+	return buf.Bytes()
+}
+
+func encodePNG(img image.Image) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,201 @@
+package picture
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	"math/bits"
+)
+
+// Bitmap compressions (biCompression).
+const (
+	biRGB       = 0
+	biBitfields = 3
+)
+
+// maxPixels bounds the size of decoded bitmaps.
+const maxPixels = 1 << 26
+
+// dibHeader holds the fields of a BITMAPCOREHEADER or BITMAPINFOHEADER
+// (and its V4 and V5 extensions) that decoding needs.
+type dibHeader struct {
+	size        int // Header size
+	width       int
+	height      int // Negative for top-down bitmaps
+	planes      int
+	bitCount    int
+	compression uint32
+	colors      int       // Palette entries
+	masks       [4]uint32 // Red, green, blue and alpha masks
+	maskBytes   int       // Size of the masks following a BITMAPINFOHEADER
+}
+
+// parseHeader parses the header of the packed bitmap b.
+func parseHeader(b []byte) (dibHeader, error) {
+	var h dibHeader
+	if len(b) < 12 {
+		return h, errors.New("bitmap header too short")
+	}
+	le := binary.LittleEndian
+	h.size = int(le.Uint32(b))
+	switch {
+	case h.size == 12:
+		h.width, h.height = int(le.Uint16(b[4:])), int(int16(le.Uint16(b[6:])))
+		h.planes, h.bitCount = int(le.Uint16(b[8:])), int(le.Uint16(b[10:]))
+	case h.size == 40 || h.size == 52 || h.size == 56 || h.size == 108 || h.size == 124:
+		if len(b) < h.size {
+			return h, errors.New("bitmap header too short")
+		}
+		h.width, h.height = int(int32(le.Uint32(b[4:]))), int(int32(le.Uint32(b[8:])))
+		h.planes, h.bitCount = int(le.Uint16(b[12:])), int(le.Uint16(b[14:]))
+		h.compression = le.Uint32(b[16:])
+		h.colors = int(le.Uint32(b[32:]))
+		if h.compression == biBitfields {
+			if h.size == 40 {
+				if len(b) < 52 {
+					return h, errors.New("bitmap masks missing")
+				}
+				h.maskBytes = 12
+			}
+			for i := 0; i < 3; i++ {
+				h.masks[i] = le.Uint32(b[40+4*i:])
+			}
+			if h.size >= 56 {
+				h.masks[3] = le.Uint32(b[52:])
+			}
+		}
+	default:
+		return h, fmt.Errorf("unknown bitmap header size %d", h.size)
+	}
+
+	switch h.bitCount {
+	case 1, 4, 8:
+		if h.colors == 0 || h.colors > 1<<h.bitCount {
+			h.colors = 1 << h.bitCount
+		}
+	case 16, 24, 32:
+		h.colors = 0
+	default:
+		return h, fmt.Errorf("unsupported bit count %d", h.bitCount)
+	}
+	if h.width <= 0 || h.height == 0 || h.width*abs(h.height) > maxPixels {
+		return h, fmt.Errorf("invalid bitmap size %dx%d", h.width, h.height)
+	}
+	return h, nil
+}
+
+// paletteEntry returns the size of a palette entry.
+func (h dibHeader) paletteEntry() int {
+	if h.size == 12 {
+		return 3 // RGBTRIPLE
+	}
+	return 4 // RGBQUAD
+}
+
+// bitsOffset returns the offset of the pixels in a packed bitmap.
+func (h dibHeader) bitsOffset() int {
+	return h.size + h.maskBytes + h.colors*h.paletteEntry()
+}
+
+// decodeDIB decodes the uncompressed bitmap b. off is the offset of the
+// pixels in b, or -1 if they directly follow the palette.
+func decodeDIB(b []byte, off int) (image.Image, error) {
+	h, err := parseHeader(b)
+	if err != nil {
+		return nil, err
+	}
+	if h.compression != biRGB && h.compression != biBitfields {
+		return nil, fmt.Errorf("unsupported bitmap compression %d", h.compression)
+	}
+	if off < 0 {
+		off = h.bitsOffset()
+	}
+	w, ht := h.width, abs(h.height)
+	stride := (w*h.bitCount + 31) / 32 * 4
+	if off < h.size || off > len(b) || stride*ht > len(b)-off {
+		return nil, errors.New("bitmap data too short")
+	}
+	pix := b[off:]
+
+	// row returns the pixels of image row y; bitmaps are stored bottom-up
+	// unless their height is negative
+	row := func(y int) []byte {
+		if h.height > 0 {
+			y = ht - 1 - y
+		}
+		return pix[y*stride : (y+1)*stride]
+	}
+
+	if h.colors > 0 {
+		palette := make(color.Palette, h.colors)
+		pe := h.paletteEntry()
+		start := h.size + h.maskBytes
+		if start+h.colors*pe > len(b) {
+			return nil, errors.New("bitmap palette too short")
+		}
+		for i := range palette {
+			p := b[start+i*pe:]
+			palette[i] = color.RGBA{R: p[2], G: p[1], B: p[0], A: 0xFF}
+		}
+		img := image.NewPaletted(image.Rect(0, 0, w, ht), palette)
+		perByte := 8 / h.bitCount
+		for y := 0; y < ht; y++ {
+			r := row(y)
+			for x := 0; x < w; x++ {
+				shift := uint(8 - h.bitCount*(x%perByte+1))
+				idx := r[x/perByte] >> shift & (1<<uint(h.bitCount) - 1)
+				if int(idx) >= h.colors {
+					idx = 0
+				}
+				img.Pix[y*img.Stride+x] = idx
+			}
+		}
+		return img, nil
+	}
+
+	masks := h.masks
+	if h.compression == biRGB {
+		switch h.bitCount {
+		case 16:
+			masks = [4]uint32{0x7C00, 0x03E0, 0x001F, 0}
+		case 24, 32:
+			masks = [4]uint32{0xFF0000, 0x00FF00, 0x0000FF, 0}
+		}
+	}
+	img := image.NewNRGBA(image.Rect(0, 0, w, ht))
+	n := h.bitCount / 8
+	for y := 0; y < ht; y++ {
+		r := row(y)
+		for x := 0; x < w; x++ {
+			var v uint32
+			for i := n - 1; i >= 0; i-- {
+				v = v<<8 | uint32(r[x*n+i])
+			}
+			a := uint8(0xFF)
+			if masks[3] != 0 {
+				a = channel(v, masks[3])
+			}
+			img.SetNRGBA(x, y, color.NRGBA{R: channel(v, masks[0]), G: channel(v, masks[1]), B: channel(v, masks[2]), A: a})
+		}
+	}
+	return img, nil
+}
+
+// channel extracts the colour channel selected by mask from v, scaled to 8 bits.
+func channel(v, mask uint32) uint8 {
+	if mask == 0 {
+		return 0
+	}
+	c := (v & mask) >> uint(bits.TrailingZeros32(mask))
+	max := uint32(1)<<uint(bits.OnesCount32(mask)) - 1
+	return uint8(c * 255 / max)
+}
+
+func abs(x int) int {
+	if x < 0 {
+		return -x
+	}
+	return x
+}
@@ -0,0 +1,178 @@
+// Package picture provides picture views (HostPictures), which embed a
+// Windows metafile, a device-independent bitmap or a Macintosh picture,
+// and converts their data to files.
+package picture
+
+import (
+	"fmt"
+	"io"
+
+	"odcread/pkg/alien"
+	"odcread/pkg/fold"
+	"odcread/pkg/oberon"
+	"odcread/pkg/store"
+)
+
+const TypeNameStdView = "HostPictures.StdView^"
+
+// Picture types.
+const (
+	MacPict   = 0 // Macintosh PICT
+	WinPict   = 1 // Windows metafile
+	WinBitmap = 2 // Windows device-independent bitmap (DIB)
+)
+
+// Display modes.
+const (
+	Clip        = 0
+	Fit         = 1
+	Approximate = 2
+)
+
+// StdView is a picture view (HostPictures.StdView).
+type StdView struct {
+	fold.View
+	typ     byte
+	w, h    oberon.Integer // Size, in universal units
+	mode    byte
+	data    []byte
+	section *io.SectionReader // Deferred data of a lazy reader
+}
+
+// NewStdView creates a new StdView instance.
+func NewStdView(id oberon.Integer) *StdView {
+	return &StdView{
+		View: *fold.NewView(id),
+	}
+}
+
+// GetTypeName returns the type name for StdView.
+func (v *StdView) GetTypeName() string {
+	return TypeNameStdView
+}
+
+// Internalize reads StdView data from the reader.
+// A lazy reader only records where the picture data is; see GetData.
+// Format (HostPictures.StdView.Internalize):
+//
+//	version (0)
+//	type (byte), w, h (int), mode (byte)
+//	data length (int), data
+func (v *StdView) Internalize(reader store.Reader) error {
+	if err := v.View.Internalize(reader); err != nil {
+		return err
+	}
+	if _, err := reader.ReadVersion(0, 0); err != nil {
+		return err
+	}
+
+	var err error
+	if v.typ, err = reader.ReadByte(); err != nil {
+		return fmt.Errorf("failed to read picture type: %w", err)
+	}
+	if v.w, err = reader.ReadInt(); err != nil {
+		return fmt.Errorf("failed to read picture width: %w", err)
+	}
+	if v.h, err = reader.ReadInt(); err != nil {
+		return fmt.Errorf("failed to read picture height: %w", err)
+	}
+	if v.mode, err = reader.ReadByte(); err != nil {
+		return fmt.Errorf("failed to read picture mode: %w", err)
+	}
+	n, err := reader.ReadInt()
+	if err != nil {
+		return fmt.Errorf("failed to read picture length: %w", err)
+	}
+	if err := reader.ReserveData(int64(n)); err != nil {
+		return fmt.Errorf("invalid picture: %w", err)
+	}
+	if v.section, err = reader.Defer(int64(n)); err != nil || v.section != nil {
+		return err
+	}
+	v.data = make([]byte, n)
+	if err := reader.ReadSChars(v.data); err != nil {
+		return fmt.Errorf("failed to read picture data: %w", err)
+	}
+	return nil
+}
+
+// String returns a string representation of the StdView.
+func (v *StdView) String() string {
+	return fmt.Sprintf("Picture{id: %d, type: %d, size: %dx%d}", v.GetID(), v.typ, v.w, v.h)
+}
+
+// GetType returns the picture type, e.g. WinBitmap.
+func (v *StdView) GetType() byte {
+	return v.typ
+}
+
+// GetSize returns the size of the picture, in universal units.
+func (v *StdView) GetSize() (w, h oberon.Integer) {
+	return v.w, v.h
+}
+
+// GetMode returns the display mode, e.g. Fit.
+func (v *StdView) GetMode() byte {
+	return v.mode
+}
+
+// GetData returns the picture data, reading deferred data first.
+// Deferred data that cannot be read is returned as nil.
+func (v *StdView) GetData() []byte {
+	if v.section != nil {
+		data := make([]byte, v.section.Size())
+		if _, err := v.section.ReadAt(data, 0); err != nil && err != io.EOF {
+			return nil
+		}
+		v.data, v.section = data, nil
+	}
+	return v.data
+}
+
+// Is reports whether s is a picture view: a StdView, or a picture view
+// that was read as an alien, e.g. because it was written by another version.
+func Is(s store.Store) bool {
+	switch s := s.(type) {
+	case *StdView:
+		return true
+	case *alien.Alien:
+		path := s.GetTypePath()
+		return len(path) > 0 && path[0] == TypeNameStdView
+	}
+	return false
+}
+
+// File returns the data of picture view s as a file with extension ext:
+// bitmaps are converted to PNG, other pictures are returned as they are
+// stored (see Convert). For a picture read as an alien, the data is
+// located by its format signature. ok is false if s has no picture data.
+func File(s store.Store) (data []byte, ext string, ok bool) {
+	switch s := s.(type) {
+	case *StdView:
+		raw := s.GetData()
+		if len(raw) == 0 {
+			return nil, "", false
+		}
+		data, ext = Convert(raw)
+		if ext == ".bin" && s.typ == MacPict {
+			ext = ".pict"
+		}
+		return data, ext, true
+	case *alien.Alien:
+		if !Is(s) {
+			return nil, "", false
+		}
+		var raw []byte
+		for _, c := range s.GetComponents() {
+			if p, ok := c.(*alien.AlienPiece); ok {
+				raw = append(raw, p.GetData()...)
+			}
+		}
+		if raw = locate(raw); raw == nil {
+			return nil, "", false
+		}
+		data, ext = Convert(raw)
+		return data, ext, true
+	}
+	return nil, "", false
+}
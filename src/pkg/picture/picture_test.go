@@ -0,0 +1,142 @@
+package picture_test
+
+import (
+	"bytes"
+	"encoding/binary"
+	"image/color"
+	"image/png"
+	"strings"
+	"testing"
+
+	"odcread/internal/testdoc"
+	"odcread/pkg/extract"
+	"odcread/pkg/picture"
+	"odcread/pkg/reader"
+	_ "odcread/pkg/typeregister" // Import for side-effect (type registration)
+)
+
+// dib returns a packed 2x2 bitmap with the given bit count, compression
+// and palette, followed by pixels.
+func dib(bitCount int, compression uint32, palette []byte, pixels []byte) []byte {
+	var b bytes.Buffer
+	for _, v := range []interface{}{
+		uint32(40), int32(2), int32(2), uint16(1), uint16(bitCount), compression,
+		uint32(len(pixels)), int32(0), int32(0), uint32(len(palette) / 4), uint32(0),
+	} {
+		binary.Write(&b, binary.LittleEndian, v)
+	}
+	b.Write(palette)
+	b.Write(pixels)
+	return b.Bytes()
+}
+
+func TestConvert_DIB(t *testing.T) {
+	// Rows are stored bottom-up and padded to 4 bytes; pixels are BGR
+	pixels := []byte{
+		0, 0, 255, 0, 255, 0, 0, 0, // bottom row: red, green
+		255, 0, 0, 255, 255, 255, 0, 0, // top row: blue, white
+	}
+	out, ext := picture.Convert(dib(24, 0, nil, pixels))
+	if ext != ".png" {
+		t.Fatalf("Expected .png, got %s", ext)
+	}
+	img, err := png.Decode(bytes.NewReader(out))
+	if err != nil {
+		t.Fatalf("Decode failed: %v", err)
+	}
+	for _, c := range []struct {
+		x, y int
+		want color.NRGBA
+	}{
+		{0, 0, color.NRGBA{0, 0, 255, 255}},
+		{1, 0, color.NRGBA{255, 255, 255, 255}},
+		{0, 1, color.NRGBA{255, 0, 0, 255}},
+		{1, 1, color.NRGBA{0, 255, 0, 255}},
+	} {
+		if got := color.NRGBAModel.Convert(img.At(c.x, c.y)); got != c.want {
+			t.Errorf("Pixel (%d, %d): expected %v, got %v", c.x, c.y, c.want, got)
+		}
+	}
+
+	// A 1-bit bitmap with a black and white palette
+	palette := []byte{0, 0, 0, 0, 255, 255, 255, 0}
+	out, ext = picture.Convert(dib(1, 0, palette, []byte{0x40, 0, 0, 0, 0x80, 0, 0, 0}))
+	if ext != ".png" {
+		t.Fatalf("Expected .png, got %s", ext)
+	}
+	img, _ = png.Decode(bytes.NewReader(out))
+	if r, _, _, _ := img.At(0, 0).RGBA(); r != 0xFFFF {
+		t.Errorf("Expected a white top left pixel, got %v", img.At(0, 0))
+	}
+	if r, _, _, _ := img.At(0, 1).RGBA(); r != 0 {
+		t.Errorf("Expected a black bottom left pixel, got %v", img.At(0, 1))
+	}
+}
+
+func TestConvert_Raw(t *testing.T) {
+	// Compressed bitmaps are saved as .bmp files
+	rle := dib(8, 1, make([]byte, 8), []byte{0, 1})
+	out, ext := picture.Convert(rle)
+	if ext != ".bmp" || !bytes.HasPrefix(out, []byte("BM")) || !bytes.HasSuffix(out, rle) {
+		t.Errorf("Expected a .bmp file, got %s (%d bytes)", ext, len(out))
+	}
+
+	emf := make([]byte, 88)
+	emf[0] = 1
+	copy(emf[40:], " EMF")
+	if _, ext := picture.Convert(emf); ext != ".emf" {
+		t.Errorf("Expected .emf, got %s", ext)
+	}
+	if _, ext := picture.Convert([]byte{0xD7, 0xCD, 0xC6, 0x9A, 0, 0}); ext != ".wmf" {
+		t.Errorf("Expected .wmf, got %s", ext)
+	}
+	if _, ext := picture.Convert([]byte("unknown")); ext != ".bin" {
+		t.Errorf("Expected .bin, got %s", ext)
+	}
+}
+
+func TestExtract_Images(t *testing.T) {
+	pic := testdoc.Picture(picture.WinBitmap, dib(24, 0, nil, make([]byte, 16)))
+	doc := testdoc.TextModel("Figure: ", testdoc.View{Store: pic}, "\rEnd")
+	s, err := reader.NewReader(bytes.NewReader(doc)).ReadStore()
+	if err != nil {
+		t.Fatalf("ReadStore failed: %v", err)
+	}
+
+	d := extract.Extract(s)
+	if len(d.Images) != 1 || d.Images[0].Pos != len("Figure: ") {
+		t.Fatalf("Unexpected images %+v", d.Images)
+	}
+	if _, ext, ok := picture.File(d.Images[0].View); !ok || ext != ".png" {
+		t.Errorf("Expected a PNG file, got %q, %v", ext, ok)
+	}
+
+	d.Images[0].Src = "img/doc-1.png"
+	var md bytes.Buffer
+	extract.WriteMarkdown(&md, d)
+	if !strings.HasPrefix(md.String(), "Figure: ![](img/doc-1.png)\n") {
+		t.Errorf("Unexpected Markdown %q", md.String())
+	}
+}
+
+func TestFile_Alien(t *testing.T) {
+	data := dib(24, 0, nil, make([]byte, 16))
+	pic := testdoc.Picture(picture.WinBitmap, data)
+	pic[len(pic)-(17+len(data))+2] = 9 // An unknown picture version
+	doc := testdoc.TextModel(testdoc.View{Store: pic})
+	s, err := reader.NewReader(bytes.NewReader(doc)).ReadStore()
+	if err != nil {
+		t.Fatalf("ReadStore failed: %v", err)
+	}
+
+	d := extract.Extract(s)
+	if len(d.Images) != 1 {
+		t.Fatalf("Expected an image, got %+v", d.Images)
+	}
+	if _, ok := d.Images[0].View.(*picture.StdView); ok {
+		t.Fatalf("Expected the picture to be read as an alien")
+	}
+	if _, ext, ok := picture.File(d.Images[0].View); !ok || ext != ".png" {
+		t.Errorf("Expected a PNG file, got %q, %v", ext, ok)
+	}
+}
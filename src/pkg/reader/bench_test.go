@@ -0,0 +1,85 @@
+package reader
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"odcread/internal/testdoc"
+)
+
+// corpusDir is the location of the .odc test corpus relative to this package.
+const corpusDir = "../../../_tests"
+
+// benchFile writes data to a temporary file and returns it opened for reading,
+// so that benchmarks measure real file I/O.
+func benchFile(b *testing.B, data []byte) *os.File {
+	b.Helper()
+	name := filepath.Join(b.TempDir(), "bench.odc")
+	if err := os.WriteFile(name, data, 0o644); err != nil {
+		b.Fatal(err)
+	}
+	f, err := os.Open(name)
+	if err != nil {
+		b.Fatal(err)
+	}
+	b.Cleanup(func() { f.Close() })
+	return f
+}
+
+// benchRead parses the store at offset from f once per iteration.
+func benchRead(b *testing.B, f *os.File, offset int64) {
+	b.Helper()
+	for i := 0; i < b.N; i++ {
+		if _, err := f.Seek(offset, 0); err != nil {
+			b.Fatal(err)
+		}
+		if _, err := NewReader(f).ReadStore(); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkReadStore_LargeText(b *testing.B) {
+	data := testdoc.TextModel(strings.Repeat("BlackBox ", 1<<17))
+	f := benchFile(b, data)
+	b.SetBytes(int64(len(data)))
+	b.ResetTimer()
+	benchRead(b, f, 0)
+}
+
+func BenchmarkReadStore_ManyStores(b *testing.B) {
+	// An alien holding a long chain of small text models
+	parts := make([]interface{}, 2000)
+	for i := range parts {
+		parts[i] = testdoc.TextModel("piece of text")
+	}
+	content, downOff := testdoc.Content(parts...)
+	data := testdoc.Store(0x82, []string{"Foo.BarDesc", "Stores.StoreDesc"}, content, downOff)
+	f := benchFile(b, data)
+	b.SetBytes(int64(len(data)))
+	b.ResetTimer()
+	benchRead(b, f, 0)
+}
+
+func BenchmarkReadStore_Corpus(b *testing.B) {
+	files, _ := filepath.Glob(filepath.Join(corpusDir, "*.odc"))
+	if len(files) == 0 {
+		b.Skip("test corpus not available")
+	}
+	for _, name := range files {
+		name := name
+		b.Run(filepath.Base(name), func(b *testing.B) {
+			f, err := os.Open(name)
+			if err != nil {
+				b.Fatal(err)
+			}
+			defer f.Close()
+			if fi, err := f.Stat(); err == nil {
+				b.SetBytes(fi.Size())
+			}
+			benchRead(b, f, 8) // skip the document tag and version
+		})
+	}
+}
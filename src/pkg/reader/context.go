@@ -0,0 +1,99 @@
+package reader
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+
+	"odcread/pkg/oberon"
+	"odcread/pkg/store"
+)
+
+// chunkSize is the number of characters read between cancellation checks.
+const chunkSize = 4096
+
+// ReadStoreContext reads a store like ReadStore, but stops when ctx is done.
+// Cancellation is checked before every store and between chunks of text pieces;
+// the returned error wraps ctx.Err() and reports the offset reached.
+func (r *Reader) ReadStoreContext(ctx context.Context) (store.Store, error) {
+	saveCtx := r.ctx
+	r.ctx = ctx
+	defer func() { r.ctx = saveCtx }()
+
+	if err := r.checkContext(); err != nil {
+		return nil, err
+	}
+	return r.ReadStore()
+}
+
+// ReadRootsContext reads roots like ReadRoots, but stops when ctx is done.
+func (r *Reader) ReadRootsContext(ctx context.Context) ([]store.Store, error) {
+	saveCtx := r.ctx
+	r.ctx = ctx
+	defer func() { r.ctx = saveCtx }()
+
+	if err := r.checkContext(); err != nil {
+		return nil, err
+	}
+	return r.ReadRoots()
+}
+
+// checkContext returns an error if the reader's context is done.
+func (r *Reader) checkContext() error {
+	if r.ctx == nil {
+		return nil
+	}
+	if err := r.ctx.Err(); err != nil {
+		return fmt.Errorf("parse stopped at offset %d: %w", r.Pos(), err)
+	}
+	return nil
+}
+
+// isContextError reports whether err was caused by a done context.
+func isContextError(err error) bool {
+	return errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded)
+}
+
+// ReadSChars fills buf with 8-bit characters.
+// On error, buf holds the characters read before the failure.
+func (r *Reader) ReadSChars(buf []oberon.ShortChar) error {
+	for i := 0; i < len(buf); i += chunkSize {
+		if err := r.checkContext(); err != nil {
+			return err
+		}
+		j := i + chunkSize
+		if j > len(buf) {
+			j = len(buf)
+		}
+		n, err := io.ReadFull(r.rider, buf[i:j])
+		if err != nil {
+			return fmt.Errorf("failed to read short char at position %d: %w", i+n, err)
+		}
+	}
+	return nil
+}
+
+// ReadLChars fills buf with 16-bit characters.
+// On error, buf holds the characters read before the failure.
+func (r *Reader) ReadLChars(buf []oberon.Char) error {
+	var raw [2 * chunkSize]byte
+	for i := 0; i < len(buf); i += chunkSize {
+		if err := r.checkContext(); err != nil {
+			return err
+		}
+		j := i + chunkSize
+		if j > len(buf) {
+			j = len(buf)
+		}
+		n, err := io.ReadFull(r.rider, raw[:2*(j-i)])
+		for k := 0; k < n/2; k++ {
+			buf[i+k] = binary.LittleEndian.Uint16(raw[2*k:])
+		}
+		if err != nil {
+			return fmt.Errorf("failed to read long char at position %d: %w", i+n/2, err)
+		}
+	}
+	return nil
+}
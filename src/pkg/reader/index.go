@@ -0,0 +1,390 @@
+package reader
+
+import (
+	"fmt"
+	"io"
+
+	"odcread/pkg/oberon"
+	"odcread/pkg/store"
+)
+
+// IndexEntry describes a store found by walking the store headers.
+type IndexEntry struct {
+	Marker   oberon.ShortChar // NIL, LINK, NEWLINK, STORE or ELEM
+	ID       oberon.Integer   // Dictionary ID (STORE, ELEM) or referenced ID (LINK, NEWLINK)
+	Path     store.TypePath   // Type path (STORE, ELEM)
+	Offset   int64            // Position of the marker
+	Content  int64            // Position of the first content byte
+	Length   int64            // Content length in bytes
+	Next     int64            // Position of the next sibling store, or 0
+	Down     int64            // Position of the first embedded store, or 0
+	Depth    int              // Nesting depth (1 for the root)
+	Parent   *IndexEntry
+	Children []*IndexEntry // Embedded stores, in file order
+}
+
+// End returns the position just after the store.
+func (e *IndexEntry) End() int64 {
+	return e.Content + e.Length
+}
+
+// Span returns the location of the store (or link) in the input.
+func (e *IndexEntry) Span() store.Span {
+	span := store.Span{
+		Marker: e.Marker, Start: e.Offset, Header: e.Offset + 1,
+		Content: e.Content, End: e.End(), Next: e.Next, Down: e.Down,
+	}
+	if e.Marker == store.STORE || e.Marker == store.ELEM {
+		span.Header = e.Content - 16 // comment, next, down, length
+	}
+	return span
+}
+
+// String returns a one-line description of the entry.
+func (e *IndexEntry) String() string {
+	switch e.Marker {
+	case store.NIL:
+		return fmt.Sprintf("NIL @%d", e.Offset)
+	case store.LINK, store.NEWLINK:
+		return fmt.Sprintf("LINK(0x%X) id=%d @%d", e.Marker, e.ID, e.Offset)
+	default:
+		return fmt.Sprintf("%s id=%d @%d [%d, %d) children=%d",
+			e.Path.String(), e.ID, e.Offset, e.Content, e.End(), len(e.Children))
+	}
+}
+
+// Index is the store structure of an input, derived from the store headers
+// without internalizing any store. Stores are materialized on demand with Load.
+type Index struct {
+	Root *IndexEntry
+
+	r        *Reader
+	entries  []*IndexEntry // All entries in file order
+	elems    []*IndexEntry // Entries by elem ID (targets of LINK)
+	stores   []*IndexEntry // Entries by store ID (targets of NEWLINK)
+	byOffset map[int64]*IndexEntry
+	cache    map[*IndexEntry]store.Store
+	types    int // Size of the complete type dictionary
+	shallow  int // Depth of the store being loaded by LoadShallow, or 0
+}
+
+// BuildIndex walks the headers of the store at the current position and all
+// stores embedded in it, and switches the reader to lazy mode: from then on
+// stores are only read through the index, and text piece contents stay in the
+// input until they are requested.
+// On return the reader is positioned after the store, as with ReadStore.
+func (r *Reader) BuildIndex() (*Index, error) {
+	if r.index != nil {
+		return nil, fmt.Errorf("reader already has an index")
+	}
+
+	ix := &Index{
+		r:        r,
+		byOffset: make(map[int64]*IndexEntry),
+		cache:    make(map[*IndexEntry]store.Store),
+	}
+	root, err := ix.scan(nil)
+	if err != nil {
+		return nil, err
+	}
+	ix.Root = root
+	ix.types = len(r.typeList)
+
+	r.state.Next = root.Next
+	r.state.End = root.End()
+	r.index = ix
+	return ix, nil
+}
+
+// Entries returns all entries in file order.
+func (ix *Index) Entries() []*IndexEntry {
+	return ix.entries
+}
+
+// At returns the entry whose marker is at the given offset, or nil.
+func (ix *Index) At(offset int64) *IndexEntry {
+	return ix.byOffset[offset]
+}
+
+// Load materializes the store described by the entry. Stores are cached, so
+// loading an entry twice (or an entry inside an already loaded store) returns
+// the same store. The reader's position is preserved.
+func (ix *Index) Load(e *IndexEntry) (store.Store, error) {
+	switch e.Marker {
+	case store.NIL:
+		return nil, nil
+	case store.LINK, store.NEWLINK:
+		target, err := ix.Target(e)
+		if err != nil {
+			return nil, err
+		}
+		return ix.Load(target)
+	}
+	if s, ok := ix.cache[e]; ok {
+		return s, nil
+	}
+	return ix.load(e)
+}
+
+// LoadShallow reads only the store described by the entry: embedded stores and
+// link targets are returned as *Stub, and nothing is cached. The result is
+// not connected to stores obtained with Load.
+func (ix *Index) LoadShallow(e *IndexEntry) (store.Store, error) {
+	switch e.Marker {
+	case store.NIL:
+		return nil, nil
+	case store.LINK, store.NEWLINK:
+		target, err := ix.Target(e)
+		if err != nil {
+			return nil, err
+		}
+		return NewStub(target, true), nil
+	}
+
+	ix.shallow = e.Depth
+	defer func() { ix.shallow = 0 }()
+	return ix.load(e)
+}
+
+// Target returns the entry a LINK or NEWLINK entry refers to.
+func (ix *Index) Target(e *IndexEntry) (*IndexEntry, error) {
+	switch e.Marker {
+	case store.LINK:
+		return ix.linkTarget(true, e.ID)
+	case store.NEWLINK:
+		return ix.linkTarget(false, e.ID)
+	}
+	return nil, fmt.Errorf("entry at %d is not a link", e.Offset)
+}
+
+// load reads the store at the entry, preserving the reader's position.
+func (ix *Index) load(e *IndexEntry) (store.Store, error) {
+	r := ix.r
+	savePos, saveState, saveDepth := r.Pos(), r.state, r.depth
+	defer func() {
+		r.typeList = r.typeList[:ix.types] // re-read type names duplicate known entries
+		r.state, r.depth = saveState, saveDepth
+		r.rider.Seek(savePos, io.SeekStart)
+	}()
+
+	r.state, r.depth = &ReaderState{}, e.Depth-1
+	if _, err := r.rider.Seek(e.Offset, io.SeekStart); err != nil {
+		return nil, err
+	}
+	return r.ReadStore()
+}
+
+// linkTarget returns the entry with the given elem (LINK) or store (NEWLINK) ID.
+func (ix *Index) linkTarget(isElem bool, id oberon.Integer) (*IndexEntry, error) {
+	list, kind := ix.stores, "store"
+	if isElem {
+		list, kind = ix.elems, "elem"
+	}
+	if id < 0 || int(id) >= len(list) {
+		return nil, fmt.Errorf("invalid %s link ID: %d", kind, id)
+	}
+	return list[id], nil
+}
+
+// scan reads the header of the store at the current position, recursively
+// scans its embedded stores, and moves past it.
+func (ix *Index) scan(parent *IndexEntry) (*IndexEntry, error) {
+	r := ix.r
+	if err := r.checkContext(); err != nil {
+		return nil, err
+	}
+
+	e := &IndexEntry{Offset: r.Pos(), Parent: parent, Depth: 1}
+	if parent != nil {
+		e.Depth = parent.Depth + 1
+	}
+
+	marker, err := r.ReadSChar()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read store marker at %d: %w", e.Offset, err)
+	}
+	e.Marker = marker
+	ix.entries = append(ix.entries, e)
+	ix.byOffset[e.Offset] = e
+
+	switch marker {
+	case store.NIL, store.LINK, store.NEWLINK:
+		if marker != store.NIL {
+			if e.ID, err = r.ReadInt(); err != nil {
+				return nil, fmt.Errorf("failed to read link ID: %w", err)
+			}
+		}
+		comment, err := r.ReadInt()
+		if err != nil {
+			return nil, fmt.Errorf("failed to read comment: %w", err)
+		}
+		next, err := r.ReadInt()
+		if err != nil {
+			return nil, fmt.Errorf("failed to read next: %w", err)
+		}
+		e.Content = r.Pos()
+		if next > 0 || (next == 0 && comment%2 == 1) {
+			e.Next = e.Content + int64(next)
+		}
+
+	case store.STORE, store.ELEM:
+		if err := ix.scanStore(e); err != nil {
+			return nil, err
+		}
+
+	default:
+		return nil, fmt.Errorf("unknown store marker at %d: 0x%X", e.Offset, marker)
+	}
+
+	return e, nil
+}
+
+// scanStore reads the header of a STORE or ELEM and scans its embedded stores.
+func (ix *Index) scanStore(e *IndexEntry) error {
+	r := ix.r
+
+	// IDs are assigned in the order the stores begin, as in readNewStore
+	if e.Marker == store.ELEM {
+		e.ID = oberon.Integer(len(ix.elems))
+		ix.elems = append(ix.elems, e)
+	} else {
+		e.ID = oberon.Integer(len(ix.stores))
+		ix.stores = append(ix.stores, e)
+	}
+
+	saveDepth := r.depth
+	defer func() { r.depth = saveDepth }()
+	r.depth = e.Depth
+	if err := r.enterStore(); err != nil {
+		return err
+	}
+
+	path, err := r.readPath()
+	if err != nil {
+		return fmt.Errorf("failed to read type path at %d: %w", e.Offset, err)
+	}
+	e.Path = path
+
+	if _, err := r.ReadInt(); err != nil { // comment
+		return fmt.Errorf("failed to read comment: %w", err)
+	}
+	pos1 := r.Pos()
+	next, err := r.ReadInt()
+	if err != nil {
+		return fmt.Errorf("failed to read next: %w", err)
+	}
+	down, err := r.ReadInt()
+	if err != nil {
+		return fmt.Errorf("failed to read down: %w", err)
+	}
+	length, err := r.ReadInt()
+	if err != nil {
+		return fmt.Errorf("failed to read length: %w", err)
+	}
+
+	e.Content = r.Pos()
+	e.Length = int64(length)
+	if err := r.checkRange("store", e.Content, e.End()); err != nil {
+		return err
+	}
+	if next > 0 {
+		e.Next = pos1 + int64(next) + 4
+	}
+	if down > 0 {
+		e.Down = pos1 + int64(down) + 8
+		if e.Down < e.Content || e.Down >= e.End() {
+			return fmt.Errorf("embedded store at %d lies outside store [%d, %d)", e.Down, e.Content, e.End())
+		}
+	}
+
+	// Follow the chain of embedded stores
+	for pos := e.Down; pos > 0; {
+		if _, err := r.rider.Seek(pos, io.SeekStart); err != nil {
+			return err
+		}
+		child, err := ix.scan(e)
+		if err != nil {
+			return err
+		}
+		e.Children = append(e.Children, child)
+
+		if child.Next == 0 || child.Next >= e.End() {
+			break
+		}
+		if child.Next <= pos {
+			return fmt.Errorf("store chain at %d does not advance", pos)
+		}
+		pos = child.Next
+	}
+
+	_, err = r.rider.Seek(e.End(), io.SeekStart)
+	return err
+}
+
+// Defer skips n bytes and returns a section from which they can be read later.
+// Outside lazy mode it returns a nil section and skips nothing.
+func (r *Reader) Defer(n int64) (*io.SectionReader, error) {
+	if r.index == nil {
+		return nil, nil
+	}
+	off := r.Pos()
+	if err := r.checkRange("deferred data", off, off+n); err != nil {
+		return nil, err
+	}
+	if _, err := r.rider.Seek(off+n, io.SeekStart); err != nil {
+		return nil, err
+	}
+	return io.NewSectionReader(r.rider, off, n), nil
+}
+
+// Stub stands in for a store that LoadShallow did not read.
+type Stub struct {
+	store.BaseStore
+	entry *IndexEntry
+	link  bool
+}
+
+// NewStub creates a stub for the store described by the entry.
+// link reports whether the store was reached through a LINK or NEWLINK.
+func NewStub(e *IndexEntry, link bool) *Stub {
+	s := &Stub{
+		BaseStore: store.NewBaseStore(e.ID),
+		entry:     e,
+		link:      link,
+	}
+	s.SetSpan(e.Span())
+	return s
+}
+
+// GetTypeName returns the type name of the store (from the path).
+func (s *Stub) GetTypeName() string {
+	if len(s.entry.Path) > 0 {
+		return s.entry.Path[0]
+	}
+	return "Stub"
+}
+
+// GetTypePath returns the type path of the store.
+func (s *Stub) GetTypePath() store.TypePath {
+	return s.entry.Path
+}
+
+// String returns a debug representation.
+func (s *Stub) String() string {
+	return fmt.Sprintf("Stub{%s}", s.entry.String())
+}
+
+// Internalize is a no-op for Stub - stubs are created by the reader.
+func (s *Stub) Internalize(reader store.Reader) error {
+	return nil
+}
+
+// Entry returns the index entry of the store.
+func (s *Stub) Entry() *IndexEntry {
+	return s.entry
+}
+
+// IsLink reports whether the store was reached through a LINK or NEWLINK.
+func (s *Stub) IsLink() bool {
+	return s.link
+}
@@ -0,0 +1,161 @@
+package reader
+
+import (
+	"fmt"
+	"io"
+)
+
+// inputBufferSize is the size of the read buffer in front of the underlying stream.
+const inputBufferSize = 32 << 10
+
+// input is a buffered, position-tracking view of an io.ReadSeeker.
+// Position queries and seeks within the buffered window never touch the
+// underlying stream.
+type input struct {
+	rs  io.ReadSeeker
+	buf []byte
+	r   int   // Read position in buf
+	w   int   // End of valid data in buf
+	off int64 // Stream position corresponding to buf[w]
+}
+
+// newInput wraps rs, starting at its current position.
+func newInput(rs io.ReadSeeker) *input {
+	off, err := rs.Seek(0, io.SeekCurrent)
+	if err != nil {
+		off = 0
+	}
+	return &input{
+		rs:  rs,
+		buf: make([]byte, inputBufferSize),
+		off: off,
+	}
+}
+
+// Pos returns the current read position.
+func (in *input) Pos() int64 {
+	return in.off - int64(in.w-in.r)
+}
+
+// fill reads more data into the buffer, discarding consumed bytes.
+func (in *input) fill() error {
+	if in.r > 0 {
+		copy(in.buf, in.buf[in.r:in.w])
+		in.w -= in.r
+		in.r = 0
+	}
+	n, err := in.rs.Read(in.buf[in.w:])
+	in.w += n
+	in.off += int64(n)
+	if n > 0 {
+		return nil
+	}
+	if err == nil {
+		err = io.ErrNoProgress
+	}
+	return err
+}
+
+// next returns the next n bytes (n <= inputBufferSize) and consumes them.
+// The returned slice is only valid until the next call.
+func (in *input) next(n int) ([]byte, error) {
+	for in.w-in.r < n {
+		if err := in.fill(); err != nil {
+			if err == io.EOF && in.w > in.r {
+				err = io.ErrUnexpectedEOF
+			}
+			return nil, err
+		}
+	}
+	b := in.buf[in.r : in.r+n]
+	in.r += n
+	return b, nil
+}
+
+// ReadByte reads a single byte.
+func (in *input) ReadByte() (byte, error) {
+	if in.r == in.w {
+		if err := in.fill(); err != nil {
+			return 0, err
+		}
+	}
+	b := in.buf[in.r]
+	in.r++
+	return b, nil
+}
+
+// Read implements io.Reader. Large reads bypass the buffer.
+func (in *input) Read(p []byte) (int, error) {
+	if len(p) == 0 {
+		return 0, nil
+	}
+	if in.r == in.w {
+		if len(p) >= len(in.buf) {
+			n, err := in.rs.Read(p)
+			in.off += int64(n)
+			return n, err
+		}
+		if err := in.fill(); err != nil {
+			return 0, err
+		}
+	}
+	n := copy(p, in.buf[in.r:in.w])
+	in.r += n
+	return n, nil
+}
+
+// Seek implements io.Seeker. Seeks within the buffered window only move the read position.
+func (in *input) Seek(offset int64, whence int) (int64, error) {
+	var target int64
+	switch whence {
+	case io.SeekStart:
+		target = offset
+	case io.SeekCurrent:
+		target = in.Pos() + offset
+	case io.SeekEnd:
+		end, err := in.rs.Seek(0, io.SeekEnd)
+		if err != nil {
+			return 0, err
+		}
+		target = end + offset
+		in.r, in.w, in.off = 0, 0, end
+	default:
+		return 0, fmt.Errorf("invalid whence: %d", whence)
+	}
+	if target < 0 {
+		return 0, fmt.Errorf("negative position: %d", target)
+	}
+
+	// Stay inside the buffered window if possible
+	if start := in.off - int64(in.w); target >= start && target <= in.off {
+		in.r = int(target - start)
+		return target, nil
+	}
+
+	if _, err := in.rs.Seek(target, io.SeekStart); err != nil {
+		return 0, err
+	}
+	in.r, in.w, in.off = 0, 0, target
+	return target, nil
+}
+
+// ReadAt implements io.ReaderAt. If the underlying stream does not support
+// ReadAt, the read position is saved and restored around the read.
+func (in *input) ReadAt(p []byte, off int64) (int, error) {
+	if ra, ok := in.rs.(io.ReaderAt); ok {
+		return ra.ReadAt(p, off)
+	}
+
+	cur := in.Pos()
+	if _, err := in.Seek(off, io.SeekStart); err != nil {
+		return 0, err
+	}
+	n, err := io.ReadFull(in, p)
+	if err == io.ErrUnexpectedEOF {
+		err = io.EOF
+	}
+	if _, serr := in.Seek(cur, io.SeekStart); serr != nil && err == nil {
+		err = serr
+	}
+	return n, err
+}
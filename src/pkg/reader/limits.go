@@ -0,0 +1,116 @@
+package reader
+
+import (
+	"errors"
+	"fmt"
+	"io"
+)
+
+// ErrLimitExceeded is wrapped by every error caused by input exceeding the reader's Limits.
+var ErrLimitExceeded = errors.New("limit exceeded")
+
+// Limits bounds the resources a Reader may spend on a single input.
+// A zero field disables the corresponding check.
+type Limits struct {
+	MaxAlloc    int64 // Largest single allocation driven by a length field in the file, in bytes
+	MaxDepth    int   // Deepest store nesting
+	MaxStores   int   // Total number of stores read (including aliens)
+	MaxTextSize int64 // Total size of all text pieces, in bytes
+}
+
+// DefaultLimits returns the limits used by NewReader.
+// They are generous enough for any real BlackBox document.
+func DefaultLimits() Limits {
+	return Limits{
+		MaxAlloc:    64 << 20,
+		MaxDepth:    512,
+		MaxStores:   1 << 20,
+		MaxTextSize: 256 << 20,
+	}
+}
+
+// SetLimits replaces the reader's resource limits.
+func (r *Reader) SetLimits(l Limits) {
+	r.limits = l
+}
+
+// Size returns the total size of the input, or -1 if it could not be determined.
+func (r *Reader) Size() int64 {
+	return r.size
+}
+
+// inputSize determines the size of rs without moving its current position.
+func inputSize(rs io.ReadSeeker) int64 {
+	cur, err := rs.Seek(0, io.SeekCurrent)
+	if err != nil {
+		return -1
+	}
+	size, err := rs.Seek(0, io.SeekEnd)
+	if err != nil {
+		return -1
+	}
+	if _, err := rs.Seek(cur, io.SeekStart); err != nil {
+		return -1
+	}
+	return size
+}
+
+// checkRange verifies that [from, to) lies inside the input.
+func (r *Reader) checkRange(what string, from, to int64) error {
+	if from < 0 || to < from {
+		return fmt.Errorf("invalid %s range [%d, %d)", what, from, to)
+	}
+	if r.size >= 0 && to > r.size {
+		return fmt.Errorf("%s range [%d, %d) extends beyond end of input (%d bytes)", what, from, to, r.size)
+	}
+	return nil
+}
+
+// checkAlloc verifies that an allocation of n bytes driven by file data is acceptable.
+func (r *Reader) checkAlloc(what string, n int64) error {
+	if n < 0 {
+		return fmt.Errorf("negative %s length: %d", what, n)
+	}
+	if r.limits.MaxAlloc > 0 && n > r.limits.MaxAlloc {
+		return fmt.Errorf("%w: %s of %d bytes exceeds maximum allocation of %d bytes", ErrLimitExceeded, what, n, r.limits.MaxAlloc)
+	}
+	return nil
+}
+
+// enterStore accounts for a new store at the current depth.
+func (r *Reader) enterStore() error {
+	r.storeCount++
+	if r.limits.MaxStores > 0 && r.storeCount > r.limits.MaxStores {
+		return fmt.Errorf("%w: more than %d stores", ErrLimitExceeded, r.limits.MaxStores)
+	}
+	if r.limits.MaxDepth > 0 && r.depth > r.limits.MaxDepth {
+		return fmt.Errorf("%w: store nesting deeper than %d", ErrLimitExceeded, r.limits.MaxDepth)
+	}
+	return nil
+}
+
+// ReserveText checks that a text piece of n bytes may be allocated and read,
+// and accounts for it against the total text size limit.
+// A lenient reader allows pieces that run past the end of the input.
+func (r *Reader) ReserveText(n int64) error {
+	if err := r.checkAlloc("text piece", n); err != nil {
+		return err
+	}
+	if pos := r.Pos(); !r.lenient && r.size >= 0 && pos >= 0 && n > r.size-pos {
+		return fmt.Errorf("text piece of %d bytes extends beyond end of input (%d bytes left)", n, r.size-pos)
+	}
+	r.textSize += n
+	if r.limits.MaxTextSize > 0 && r.textSize > r.limits.MaxTextSize {
+		return fmt.Errorf("%w: total text size exceeds %d bytes", ErrLimitExceeded, r.limits.MaxTextSize)
+	}
+	return nil
+}
+
+// ReserveData checks that a binary payload of n bytes, such as a picture,
+// may be allocated and read. Unlike text, payloads must lie inside the input.
+func (r *Reader) ReserveData(n int64) error {
+	if err := r.checkAlloc("data", n); err != nil {
+		return err
+	}
+	return r.checkRange("data", r.Pos(), r.Pos()+n)
+}
@@ -2,11 +2,15 @@
 package reader
 
 import (
+	"context"
 	"encoding/binary"
 	"fmt"
 	"io"
+	"math"
+	"unicode/utf16"
 
 	"odcread/pkg/alien"
+	"odcread/pkg/encoding"
 	"odcread/pkg/oberon"
 	"odcread/pkg/store"
 	"odcread/pkg/typeregister"
@@ -16,6 +20,10 @@ import (
 const (
 	TypeNotFound = 1 // Type not registered
 	AlienVersion = 2 // Version out of range
+
+	AlienComponent      = store.AlienComponent // Embedded store of an unexpected type
+	InconsistentVersion = 4                    // Internalize did not end at the store's end
+	InternalizeFailed   = 5                    // Internalize rejected the store's data
 )
 
 // TypeEntry represents a type in the type dictionary.
@@ -32,7 +40,7 @@ type ReaderState struct {
 
 // Reader reads binary .odc format and manages parsing state.
 type Reader struct {
-	rider        io.ReadSeeker
+	rider        *input
 	cancelled    bool
 	cause        int
 	readAlien    bool
@@ -41,59 +49,119 @@ type Reader struct {
 	storeList    []store.Store
 	currentStore store.Store
 	state        *ReaderState
+	tracer       Tracer
+	depth        int
+	limits       Limits
+	size         int64
+	storeCount   int
+	textSize     int64
+	lenient      bool
+	damages      []Damage
+	ctx          context.Context
+	index        *Index
 }
 
 // NewReader creates a new Reader for the given input stream.
 func NewReader(r io.ReadSeeker) *Reader {
 	return &Reader{
-		rider:     r,
+		rider:     newInput(r),
 		typeList:  make([]*TypeEntry, 0),
 		elemList:  make([]store.Store, 0),
 		storeList: make([]store.Store, 0),
 		state:     &ReaderState{},
+		limits:    DefaultLimits(),
+		size:      inputSize(r),
 	}
 }
 
+// Pos returns the current input position.
+func (r *Reader) Pos() int64 {
+	return r.rider.Pos()
+}
+
 // ReadSChar reads a single 8-bit character.
 func (r *Reader) ReadSChar() (oberon.ShortChar, error) {
-	var ch oberon.ShortChar
-	err := binary.Read(r.rider, binary.LittleEndian, &ch)
-	return ch, err
+	return r.rider.ReadByte()
 }
 
 // ReadLChar reads a single 16-bit character.
 func (r *Reader) ReadLChar() (oberon.Char, error) {
-	var ch oberon.Char
-	err := binary.Read(r.rider, binary.LittleEndian, &ch)
-	return ch, err
+	b, err := r.rider.next(2)
+	if err != nil {
+		return 0, err
+	}
+	return binary.LittleEndian.Uint16(b), nil
 }
 
 // ReadByte reads a single unsigned byte (implements io.ByteReader).
 func (r *Reader) ReadByte() (byte, error) {
-	var b byte
-	err := binary.Read(r.rider, binary.LittleEndian, &b)
-	return b, err
+	return r.rider.ReadByte()
 }
 
 // ReadSignedByte reads a single signed byte.
 func (r *Reader) ReadSignedByte() (oberon.Byte, error) {
-	var b oberon.Byte
-	err := binary.Read(r.rider, binary.LittleEndian, &b)
-	return b, err
+	b, err := r.rider.ReadByte()
+	return oberon.Byte(b), err
 }
 
 // ReadSInt reads a 16-bit signed integer.
 func (r *Reader) ReadSInt() (oberon.ShortInt, error) {
-	var val oberon.ShortInt
-	err := binary.Read(r.rider, binary.LittleEndian, &val)
-	return val, err
+	b, err := r.rider.next(2)
+	if err != nil {
+		return 0, err
+	}
+	return oberon.ShortInt(binary.LittleEndian.Uint16(b)), nil
 }
 
 // ReadInt reads a 32-bit signed integer.
 func (r *Reader) ReadInt() (oberon.Integer, error) {
-	var val oberon.Integer
-	err := binary.Read(r.rider, binary.LittleEndian, &val)
-	return val, err
+	b, err := r.rider.next(4)
+	if err != nil {
+		return 0, err
+	}
+	return oberon.Integer(binary.LittleEndian.Uint32(b)), nil
+}
+
+// ReadBool reads a BOOLEAN (one byte; any non-zero value is TRUE).
+func (r *Reader) ReadBool() (oberon.Boolean, error) {
+	b, err := r.rider.ReadByte()
+	return b != 0, err
+}
+
+// ReadSet reads a 32-bit SET.
+func (r *Reader) ReadSet() (oberon.Set, error) {
+	b, err := r.rider.next(4)
+	if err != nil {
+		return 0, err
+	}
+	return binary.LittleEndian.Uint32(b), nil
+}
+
+// ReadLong reads a 64-bit signed integer (LONGINT).
+func (r *Reader) ReadLong() (oberon.LongInt, error) {
+	b, err := r.rider.next(8)
+	if err != nil {
+		return 0, err
+	}
+	return oberon.LongInt(binary.LittleEndian.Uint64(b)), nil
+}
+
+// ReadSReal reads a 32-bit IEEE 754 number (SHORTREAL).
+func (r *Reader) ReadSReal() (oberon.ShortReal, error) {
+	b, err := r.rider.next(4)
+	if err != nil {
+		return 0, err
+	}
+	return math.Float32frombits(binary.LittleEndian.Uint32(b)), nil
+}
+
+// ReadReal reads a 64-bit IEEE 754 number (REAL).
+func (r *Reader) ReadReal() (oberon.Real, error) {
+	b, err := r.rider.next(8)
+	if err != nil {
+		return 0, err
+	}
+	return math.Float64frombits(binary.LittleEndian.Uint64(b)), nil
 }
 
 // ReadSString reads a null-terminated short string.
@@ -107,11 +175,43 @@ func (r *Reader) ReadSString() (string, error) {
 		if ch == 0 {
 			break
 		}
+		if err := r.checkAlloc("string", int64(len(chars)+1)); err != nil {
+			return "", err
+		}
 		chars = append(chars, ch)
 	}
 	return string(chars), nil
 }
 
+// ReadString reads a null-terminated string of 16-bit characters.
+func (r *Reader) ReadString() (string, error) {
+	var chars []oberon.Char
+	for {
+		ch, err := r.ReadLChar()
+		if err != nil {
+			return "", err
+		}
+		if ch == 0 {
+			break
+		}
+		if err := r.checkAlloc("string", 2*int64(len(chars)+1)); err != nil {
+			return "", err
+		}
+		chars = append(chars, ch)
+	}
+	return string(utf16.Decode(chars)), nil
+}
+
+// ReadXString reads a null-terminated string of 8-bit characters: UTF-8
+// in current files, Latin-1 in older ones (see encoding.DecodeXString).
+func (r *Reader) ReadXString() (string, error) {
+	s, err := r.ReadSString()
+	if err != nil {
+		return "", err
+	}
+	return encoding.DecodeXString(s), nil
+}
+
 // ReadVersion reads and validates a version byte.
 // If the version is not in [min, max], the current store is turned into an alien.
 func (r *Reader) ReadVersion(min, max oberon.Integer) (oberon.Integer, error) {
@@ -123,6 +223,7 @@ func (r *Reader) ReadVersion(min, max oberon.Integer) (oberon.Integer, error) {
 	version := oberon.Integer(versionByte)
 
 	if version < min || version > max {
+		r.trace(Event{Kind: VersionMismatch, Offset: r.Pos() - 1, Version: version, Min: min, Max: max})
 		r.TurnIntoAlien(AlienVersion)
 		return version, fmt.Errorf("version %d out of range [%d, %d]", version, min, max)
 	}
@@ -150,6 +251,12 @@ func (r *Reader) ReadStore() (store.Store, error) {
 
 // readStoreOrElemStore reads either a Store or Elem-type store.
 func (r *Reader) readStoreOrElemStore() (store.Store, error) {
+	if err := r.checkContext(); err != nil {
+		return nil, err
+	}
+
+	start := r.Pos()
+
 	// Read the store marker
 	marker, err := r.ReadSChar()
 	if err != nil {
@@ -160,11 +267,11 @@ func (r *Reader) readStoreOrElemStore() (store.Store, error) {
 	case store.NIL:
 		return r.readNilStore()
 	case store.LINK:
-		return r.readLinkStore()
+		return r.readLinkStore(start)
 	case store.NEWLINK:
-		return r.readNewLinkStore()
+		return r.readNewLinkStore(start)
 	case store.STORE, store.ELEM:
-		return r.readNewStore(marker == store.ELEM)
+		return r.readNewStore(start, marker)
 	default:
 		return nil, fmt.Errorf("unknown store marker: 0x%X", marker)
 	}
@@ -201,7 +308,7 @@ func (r *Reader) readNilStore() (store.Store, error) {
 }
 
 // readLinkStore reads a link to an Elem-type store.
-func (r *Reader) readLinkStore() (store.Store, error) {
+func (r *Reader) readLinkStore(start int64) (store.Store, error) {
 	// LINK stores have full headers: id, comment, next (12 bytes total)
 	// From Component Pascal: rd.ReadInt(id); rd.ReadInt(comment); rd.ReadInt(next);
 	id, err := r.ReadInt()
@@ -234,15 +341,18 @@ func (r *Reader) readLinkStore() (store.Store, error) {
 	}
 
 	// Look up in elem list
-	if id < 0 || int(id) >= len(r.elemList) {
-		return nil, fmt.Errorf("invalid elem link ID: %d", id)
+	target, err := r.lookupLink(true, id)
+	if err != nil {
+		return nil, err
 	}
+	r.addLink(target, store.LINK, start)
 
-	return r.elemList[id], nil
+	r.trace(Event{Kind: LinkResolved, Offset: start, Marker: store.LINK, ID: id, Store: target})
+	return target, nil
 }
 
 // readNewLinkStore reads a link to a non-Elem-type store.
-func (r *Reader) readNewLinkStore() (store.Store, error) {
+func (r *Reader) readNewLinkStore(start int64) (store.Store, error) {
 	// NEWLINK stores have full headers: id, comment, next (12 bytes total)
 	// From Component Pascal: rd.ReadInt(id); rd.ReadInt(comment); rd.ReadInt(next);
 	id, err := r.ReadInt()
@@ -275,19 +385,50 @@ func (r *Reader) readNewLinkStore() (store.Store, error) {
 	}
 
 	// Look up in store list
-	if id < 0 || int(id) >= len(r.storeList) {
-		return nil, fmt.Errorf("invalid store link ID: %d", id)
+	target, err := r.lookupLink(false, id)
+	if err != nil {
+		return nil, err
 	}
+	r.addLink(target, store.NEWLINK, start)
 
-	return r.storeList[id], nil
+	r.trace(Event{Kind: LinkResolved, Offset: start, Marker: store.NEWLINK, ID: id, Store: target})
+	return target, nil
 }
 
 // readNewStore reads a new store (not a link).
-func (r *Reader) readNewStore(isElem bool) (store.Store, error) {
-	// Calculate the store ID
-	id := oberon.Integer(len(r.elemList))
-	if !isElem {
-		id = oberon.Integer(len(r.storeList))
+func (r *Reader) readNewStore(start int64, marker oberon.ShortChar) (store.Store, error) {
+	isElem := marker == store.ELEM
+
+	// Calculate the store ID; IDs are assigned in the order the stores begin
+	var id oberon.Integer
+	if r.index != nil {
+		entry := r.index.byOffset[start]
+		if entry == nil {
+			return nil, fmt.Errorf("store at %d is not in the index", start)
+		}
+		s, ok := r.index.cache[entry]
+		if r.index.shallow > 0 && entry.Depth > r.index.shallow {
+			s, ok = NewStub(entry, false), true
+		}
+		if ok {
+			// Already materialized or not wanted - skip over it
+			r.state.Next = entry.Next
+			r.state.End = entry.End()
+			if _, err := r.rider.Seek(entry.End(), io.SeekStart); err != nil {
+				return nil, err
+			}
+			return s, nil
+		}
+		id = entry.ID
+	} else {
+		id = r.reserveID(isElem)
+	}
+
+	r.depth++
+	defer func() { r.depth-- }()
+	r.trace(Event{Kind: StoreBegin, Offset: start, Marker: marker, ID: id})
+	if err := r.enterStore(); err != nil {
+		return nil, err
 	}
 
 	// Read the type path
@@ -334,9 +475,21 @@ func (r *Reader) readNewStore(isElem bool) (store.Store, error) {
 		return nil, fmt.Errorf("failed to get position after header: %w", err)
 	}
 
+	// Validate the header against the input before trusting it.
+	// A lenient reader keeps going so that truncated files still yield their text.
+	if err := r.checkRange("store", pos, pos+int64(length)); err != nil && (!r.lenient || length < 0) {
+		return nil, err
+	}
+
 	// Calculate state positions
 	if next > 0 {
 		r.state.Next = pos1 + int64(next) + 4
+		if err := r.checkRange("next store", pos, r.state.Next); err != nil {
+			if !r.lenient {
+				return nil, err
+			}
+			r.state.Next = 0
+		}
 	} else {
 		r.state.Next = 0
 	}
@@ -344,17 +497,35 @@ func (r *Reader) readNewStore(isElem bool) (store.Store, error) {
 	var downPos int64
 	if down > 0 {
 		downPos = pos1 + int64(down) + 8
+		err := r.checkRange("embedded store", pos, downPos)
+		if err == nil && downPos > pos+int64(length) {
+			err = fmt.Errorf("embedded store at %d lies outside store [%d, %d)", downPos, pos, pos+int64(length))
+		}
+		if err != nil {
+			if !r.lenient {
+				return nil, err
+			}
+			downPos = 0
+		}
 	}
 
 	r.state.End = pos + int64(length)
 	r.cause = 0
+	span := store.Span{
+		Marker: marker, Start: start, Header: pos1 - 4, Content: pos,
+		End: r.state.End, Next: r.state.Next, Down: downPos,
+	}
 
 	// Try to create a store instance from the type registry
 	proxy := typeregister.GetInstance().Get(typeName)
+	r.trace(Event{Kind: TypeResolved, Offset: start, ID: id, Path: path, Registered: proxy != nil})
 	var st store.Store
 
 	if proxy != nil {
 		st = proxy.NewInstance(id)
+		st.SetTypePath(path)
+		st.SetSpan(span)
+		r.register(isElem, id, start, st)
 	} else {
 		r.cause = TypeNotFound
 	}
@@ -366,33 +537,51 @@ func (r *Reader) readNewStore(isElem bool) (store.Store, error) {
 
 		// Save the current state and create new state for nested reads
 		saveState := r.state
+		saveMark := r.mark()
 		r.state = &ReaderState{}
 
 		// Internalize the store
-		st.Internalize(r)
+		err = st.Internalize(r)
 
 		// Restore the state
 		r.state = saveState
 
-		// If internalization failed, turn it into an alien
+		// Verify we're at the expected position using the SAVED end position.
+		// As in Stores.Reader, a store that does not end there was written by
+		// another version of its type and is read as an alien instead; as
+		// this may also be a decoder that reads the wrong fields, it is
+		// reported as a damage. So is a store whose data its decoder
+		// rejects, unless the input failed or is damaged within the store.
+		var mismatch *Damage
+		if r.cause == 0 && err == nil {
+			if currentPos, _ := r.rider.Seek(0, io.SeekCurrent); currentPos != storeEnd {
+				r.TurnIntoAlien(InconsistentVersion)
+				mismatch = &Damage{Offset: start, Resume: storeEnd, Path: path, Alien: true,
+					Err: fmt.Errorf("internalize ended at %d, not at the end of the store", currentPos)}
+			}
+		} else if r.cause == 0 && isFormatError(err) && r.intact(saveMark, storeEnd) {
+			r.TurnIntoAlien(InternalizeFailed)
+			mismatch = &Damage{Offset: start, Resume: storeEnd, Path: path, Alien: true,
+				Err: fmt.Errorf("failed to internalize %s: %w", typeName, err)}
+		}
+
+		// If internalization failed, turn it into an alien.
+		// The alien re-reads the nested stores, so forget the ones read so far.
 		if r.cause != 0 {
 			st = nil
-		} else {
-			// Verify we're at the expected position using the SAVED end position
-			currentPos, _ := r.rider.Seek(0, io.SeekCurrent)
-			if currentPos != storeEnd {
-				return nil, fmt.Errorf("position mismatch after internalize: expected %d, got %d", storeEnd, currentPos)
+			r.rollback(saveMark)
+			if mismatch != nil {
+				r.damages = append(r.damages, *mismatch)
 			}
+		} else if err != nil {
+			return r.salvage(isElem, id, path, span, st,
+				fmt.Errorf("failed to internalize %s: %w", typeName, err))
 		}
 	}
 
-	// If we have a valid store, add it to the appropriate list
+	// If we have a valid store, it is complete
 	if st != nil {
-		if isElem {
-			r.elemList = append(r.elemList, st)
-		} else {
-			r.storeList = append(r.storeList, st)
-		}
+		r.trace(Event{Kind: StoreEnd, Offset: r.Pos(), ID: id, Path: path, Store: st})
 		return st, nil
 	}
 
@@ -400,12 +589,10 @@ func (r *Reader) readNewStore(isElem bool) (store.Store, error) {
 	r.rider.Seek(pos, io.SeekStart)
 
 	alienStore := alien.NewAlien(id, path)
+	alienStore.SetSpan(span)
+	r.trace(Event{Kind: AlienCreated, Offset: start, ID: id, Path: path, Cause: r.cause})
 
-	if isElem {
-		r.elemList = append(r.elemList, alienStore)
-	} else {
-		r.storeList = append(r.storeList, alienStore)
-	}
+	r.register(isElem, id, start, alienStore)
 
 	// Save the store's end position BEFORE swapping states
 	// This is critical for nested aliens - we need the actual end position, not the empty state's End
@@ -418,7 +605,8 @@ func (r *Reader) readNewStore(isElem bool) (store.Store, error) {
 	err = r.internalizeAlien(alienStore, downPos, storeEnd)
 	if err != nil {
 		r.state = saveState
-		return nil, fmt.Errorf("failed to internalize alien: %w", err)
+		return r.salvage(isElem, id, path, span, alienStore,
+			fmt.Errorf("failed to internalize alien: %w", err))
 	}
 
 	r.state = saveState
@@ -426,7 +614,8 @@ func (r *Reader) readNewStore(isElem bool) (store.Store, error) {
 	// Verify position after alien internalization using the SAVED end position
 	currentPos, _ := r.rider.Seek(0, io.SeekCurrent)
 	if currentPos != storeEnd {
-		return nil, fmt.Errorf("position mismatch after alien: expected %d, got %d", storeEnd, currentPos)
+		return r.salvage(isElem, id, path, span, alienStore,
+			fmt.Errorf("position mismatch after alien: expected %d, got %d", storeEnd, currentPos))
 	}
 
 	// Reset state after reading alien
@@ -434,5 +623,84 @@ func (r *Reader) readNewStore(isElem bool) (store.Store, error) {
 	r.cancelled = false
 	r.readAlien = true
 
+	r.trace(Event{Kind: StoreEnd, Offset: currentPos, ID: id, Path: path, Store: alienStore})
 	return alienStore, nil
 }
+
+// reserveID allocates the next ID in the elem or store dictionary.
+// The slot is filled by register once the store has been created.
+func (r *Reader) reserveID(isElem bool) oberon.Integer {
+	if isElem {
+		r.elemList = append(r.elemList, nil)
+		return oberon.Integer(len(r.elemList) - 1)
+	}
+	r.storeList = append(r.storeList, nil)
+	return oberon.Integer(len(r.storeList) - 1)
+}
+
+// register records the store for the given ID so that later links can refer to it.
+func (r *Reader) register(isElem bool, id oberon.Integer, start int64, s store.Store) {
+	if r.index != nil {
+		if r.index.shallow == 0 {
+			r.index.cache[r.index.byOffset[start]] = s
+		}
+		return
+	}
+	if isElem {
+		r.elemList[id] = s
+	} else {
+		r.storeList[id] = s
+	}
+}
+
+// lookupLink returns the store an elem (LINK) or store (NEWLINK) ID refers to.
+func (r *Reader) lookupLink(isElem bool, id oberon.Integer) (store.Store, error) {
+	if r.index != nil {
+		target, err := r.index.linkTarget(isElem, id)
+		if err != nil {
+			return nil, err
+		}
+		if r.index.shallow > 0 {
+			return NewStub(target, true), nil
+		}
+		return r.index.Load(target)
+	}
+	list, kind := r.storeList, "store"
+	if isElem {
+		list, kind = r.elemList, "elem"
+	}
+	if id < 0 || int(id) >= len(list) {
+		return nil, fmt.Errorf("invalid %s link ID: %d", kind, id)
+	}
+	return list[id], nil
+}
+
+// addLink records the span of the link just read on its target.
+func (r *Reader) addLink(target store.Store, marker oberon.ShortChar, start int64) {
+	if target == nil {
+		return
+	}
+	target.AddLink(store.Span{
+		Marker: marker, Start: start, Header: start + 1,
+		Content: r.state.End, End: r.state.End, Next: r.state.Next,
+	})
+}
+
+// dictMark records the sizes of the reader's dictionaries.
+type dictMark struct {
+	types, elems, stores, damages int
+}
+
+// mark returns the current sizes of the type, elem and store dictionaries.
+func (r *Reader) mark() dictMark {
+	return dictMark{len(r.typeList), len(r.elemList), len(r.storeList), len(r.damages)}
+}
+
+// rollback truncates the dictionaries to the sizes recorded by mark. The
+// damages recorded since are dropped too, as the stores are read again.
+func (r *Reader) rollback(m dictMark) {
+	r.typeList = r.typeList[:m.types]
+	r.elemList = r.elemList[:m.elems]
+	r.storeList = r.storeList[:m.stores]
+	r.damages = r.damages[:m.damages]
+}
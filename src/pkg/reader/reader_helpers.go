@@ -94,6 +94,10 @@ func (r *Reader) readPath() (store.TypePath, error) {
 			if typeID < 0 || int(typeID) >= len(r.typeList) {
 				return nil, fmt.Errorf("invalid type ID: %d", typeID)
 			}
+			// A well-formed chain visits each dictionary entry at most once
+			if len(path) > len(r.typeList) {
+				return nil, fmt.Errorf("cyclic type path at type ID %d", typeID)
+			}
 
 			path = append(path, r.typeList[typeID].Name)
 			typeID = r.typeList[typeID].BaseID
@@ -126,16 +130,31 @@ func (r *Reader) internalizeAlien(alienStore *alien.Alien, down, end int64) erro
 		if currentPos < next {
 			// Read a piece (unstructured binary data)
 			length := next - currentPos
-			buf := make([]byte, length)
-			n, err := r.rider.Read(buf)
+			if err := r.checkRange("alien piece", currentPos, next); err != nil {
+				return err
+			}
+			span := store.Span{Start: currentPos, Content: currentPos, End: next}
+			section, err := r.Defer(length)
 			if err != nil {
-				return fmt.Errorf("failed to read alien piece: %w", err)
+				return err
+			}
+			if section != nil {
+				piece := alien.NewDeferredAlienPiece(section)
+				piece.SetSpan(span)
+				alienStore.AddComponent(piece)
+				continue
 			}
-			if int64(n) != length {
-				return fmt.Errorf("short read: expected %d bytes, got %d", length, n)
+			if err := r.checkAlloc("alien piece", length); err != nil {
+				return err
+			}
+			buf := make([]byte, length)
+			n, err := io.ReadFull(r.rider, buf)
+			if err != nil {
+				return fmt.Errorf("short read: expected %d bytes, got %d: %w", length, n, err)
 			}
 
 			piece := alien.NewAlienPiece(buf)
+			piece.SetSpan(span)
 			alienStore.AddComponent(piece)
 
 		} else {
@@ -155,12 +174,16 @@ func (r *Reader) internalizeAlien(alienStore *alien.Alien, down, end int64) erro
 			// Get current position after reading the store
 			_, _ = r.rider.Seek(0, io.SeekCurrent)
 
-			// Update next position
+			// Update next position; the chain must move forward and stay inside the alien
+			storePos := next
 			if r.state.Next > 0 {
 				next = r.state.Next
 			} else {
 				next = end
 			}
+			if next <= storePos || next > end {
+				return fmt.Errorf("store chain at %d points outside alien: next %d, end %d", storePos, next, end)
+			}
 		}
 	}
 
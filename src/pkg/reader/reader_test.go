@@ -0,0 +1,484 @@
+package reader
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"odcread/internal/testdoc"
+	"odcread/pkg/alien"
+	"odcread/pkg/store"
+	"odcread/pkg/textmodel"
+	"odcread/pkg/typeregister"
+)
+
+// alienDoc encodes an unregistered store with a raw prefix and an embedded text model.
+func alienDoc(text string) []byte {
+	content, downOff := testdoc.Content(testdoc.Raw{1, 2, 3}, testdoc.TextModel(text))
+	return testdoc.Store(byte(store.STORE), []string{"Foo.BarDesc", "Stores.StoreDesc"}, content, downOff)
+}
+
+func TestReadStore_TextModel(t *testing.T) {
+	r := NewReader(bytes.NewReader(testdoc.TextModel("Hello")))
+	s, err := r.ReadStore()
+	if err != nil {
+		t.Fatalf("ReadStore failed: %v", err)
+	}
+	tm, ok := s.(*textmodel.StdTextModel)
+	if !ok {
+		t.Fatalf("Expected *StdTextModel, got %T", s)
+	}
+	if len(tm.GetPieces()) != 1 {
+		t.Fatalf("Expected 1 piece, got %d", len(tm.GetPieces()))
+	}
+}
+
+func TestReadStore_Alien(t *testing.T) {
+	r := NewReader(bytes.NewReader(alienDoc("Hi")))
+	s, err := r.ReadStore()
+	if err != nil {
+		t.Fatalf("ReadStore failed: %v", err)
+	}
+	a, ok := s.(*alien.Alien)
+	if !ok {
+		t.Fatalf("Expected *Alien, got %T", s)
+	}
+	comps := a.GetComponents()
+	if len(comps) != 2 {
+		t.Fatalf("Expected 2 components, got %d", len(comps))
+	}
+	if _, ok := comps[1].(*alien.AlienPart).GetStore().(*textmodel.StdTextModel); !ok {
+		t.Errorf("Expected embedded StdTextModel, got %s", comps[1].String())
+	}
+}
+
+func TestTracer(t *testing.T) {
+	var events []Event
+	r := NewReader(bytes.NewReader(alienDoc("Hi")))
+	r.SetTracer(TracerFunc(func(ev Event) { events = append(events, ev) }))
+	if _, err := r.ReadStore(); err != nil {
+		t.Fatalf("ReadStore failed: %v", err)
+	}
+
+	want := []EventKind{
+		StoreBegin, TypeResolved, AlienCreated,
+		StoreBegin, TypeResolved, StoreEnd,
+		StoreEnd,
+	}
+	if len(events) != len(want) {
+		t.Fatalf("Expected %d events, got %d: %v", len(want), len(events), events)
+	}
+	for i, ev := range events {
+		if ev.Kind != want[i] {
+			t.Errorf("Event %d: expected %s, got %s", i, want[i], ev.Kind)
+		}
+	}
+	if events[0].Offset != 0 || events[0].Depth != 1 {
+		t.Errorf("Unexpected root StoreBegin: %s (depth %d)", events[0], events[0].Depth)
+	}
+	if events[3].Depth != 2 {
+		t.Errorf("Expected nested store at depth 2, got %d", events[3].Depth)
+	}
+	if events[1].Registered || !events[4].Registered {
+		t.Errorf("Unexpected registration flags: %v, %v", events[1].Registered, events[4].Registered)
+	}
+	last := events[len(events)-1]
+	if last.Offset != int64(len(alienDoc("Hi"))) {
+		t.Errorf("Expected StoreEnd at %d, got %d", len(alienDoc("Hi")), last.Offset)
+	}
+}
+
+func TestLimits_StoreBeyondInput(t *testing.T) {
+	data := testdoc.TextModel("Hello")
+	data = data[:len(data)-2] // truncate the piece content
+	r := NewReader(bytes.NewReader(data))
+	if _, err := r.ReadStore(); err == nil {
+		t.Fatal("Expected error for truncated store")
+	}
+}
+
+func TestLimits_MaxTextSize(t *testing.T) {
+	r := NewReader(bytes.NewReader(testdoc.TextModel("Hello")))
+	r.SetLimits(Limits{MaxTextSize: 4})
+	_, err := r.ReadStore()
+	if !errors.Is(err, ErrLimitExceeded) {
+		t.Fatalf("Expected ErrLimitExceeded, got %v", err)
+	}
+}
+
+func TestLimits_MaxDepth(t *testing.T) {
+	r := NewReader(bytes.NewReader(alienDoc("Hi")))
+	r.SetLimits(Limits{MaxDepth: 1})
+	_, err := r.ReadStore()
+	if !errors.Is(err, ErrLimitExceeded) {
+		t.Fatalf("Expected ErrLimitExceeded, got %v", err)
+	}
+}
+
+func TestLimits_CyclicTypePath(t *testing.T) {
+	buf := new(bytes.Buffer)
+	buf.WriteByte(byte(store.STORE))
+	buf.WriteByte(byte(store.NEWEXT))
+	buf.WriteString("A\x00")
+	buf.WriteByte(byte(store.OLDTYPE))
+	testdoc.LE(buf, 0) // refers back to "A" itself
+	r := NewReader(bytes.NewReader(buf.Bytes()))
+	if _, err := r.ReadStore(); err == nil {
+		t.Fatal("Expected error for cyclic type path")
+	}
+}
+
+func TestLenient_Truncated(t *testing.T) {
+	data := alienDoc("Hello world")
+	data = data[:len(data)-4]
+
+	r := NewReader(bytes.NewReader(data))
+	r.SetLenient(true)
+	s, err := r.ReadStore()
+	if err != nil {
+		t.Fatalf("ReadStore failed in lenient mode: %v", err)
+	}
+	d, ok := s.(*alien.Damaged)
+	if !ok {
+		t.Fatalf("Expected *Damaged root, got %T", s)
+	}
+	if len(r.Damages()) == 0 {
+		t.Error("Expected damages to be reported")
+	}
+
+	// The embedded text model keeps the text read before the truncation
+	a := d.GetPartial().(*alien.Alien)
+	part := a.GetComponents()[1].(*alien.AlienPart).GetStore().(*alien.Damaged)
+	sp := part.GetPartial().(*textmodel.StdTextModel).GetPieces()[0].(*textmodel.ShortPiece)
+	if got := string(sp.GetBuffer()[:7]); got != "Hello w" {
+		t.Errorf("Expected salvaged text %q, got %q", "Hello w", got)
+	}
+}
+
+func TestReadStoreContext_Canceled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	r := NewReader(bytes.NewReader(alienDoc("Hi")))
+	r.SetLenient(true) // cancellation is never salvaged
+	_, err := r.ReadStoreContext(ctx)
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("Expected context.Canceled, got %v", err)
+	}
+}
+
+func TestIndex_Lazy(t *testing.T) {
+	data := alienDoc("Hello")
+	r := NewReader(bytes.NewReader(data))
+	ix, err := r.BuildIndex()
+	if err != nil {
+		t.Fatalf("BuildIndex failed: %v", err)
+	}
+	if r.Pos() != int64(len(data)) {
+		t.Errorf("Expected position %d after BuildIndex, got %d", len(data), r.Pos())
+	}
+
+	// The root, the text model and the model's NIL attributes
+	entries := ix.Entries()
+	if len(entries) != 3 {
+		t.Fatalf("Expected 3 entries, got %d: %v", len(entries), entries)
+	}
+	root, model := entries[0], entries[1]
+	if root != ix.Root || len(root.Children) != 1 || root.Children[0] != model {
+		t.Fatalf("Unexpected root entry: %s", root)
+	}
+	if model.Path[0] != "TextModels.StdModel^" || model.ID != 0 || model.Depth != 2 {
+		t.Errorf("Unexpected model entry: %s (depth %d)", model, model.Depth)
+	}
+	if model.Parent != root || model.End() != int64(len(data)) {
+		t.Errorf("Unexpected model placement: %s", model)
+	}
+
+	s, err := ix.Load(model)
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+	tm, ok := s.(*textmodel.StdTextModel)
+	if !ok {
+		t.Fatalf("Expected *StdTextModel, got %T", s)
+	}
+	if r.Pos() != int64(len(data)) {
+		t.Errorf("Load moved the reader to %d", r.Pos())
+	}
+	sp := tm.GetPieces()[0].(*textmodel.ShortPiece)
+	if got := string(sp.GetBuffer()[:5]); got != "Hello" {
+		t.Errorf("Expected deferred text %q, got %q", "Hello", got)
+	}
+
+	// Loading the root reuses the already loaded model
+	s, err = ix.Load(root)
+	if err != nil {
+		t.Fatalf("Load root failed: %v", err)
+	}
+	part := s.(*alien.Alien).GetComponents()[1].(*alien.AlienPart)
+	if part.GetStore() != tm {
+		t.Error("Expected root to embed the cached model")
+	}
+}
+
+func TestSpans(t *testing.T) {
+	// An alien holding raw data, a text model and a LINK to that text model
+	model := testdoc.TextModel("Hello")
+	link := []byte{byte(store.LINK), 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0}
+	content, downOff := testdoc.Content(testdoc.Raw{1, 2, 3}, model, link)
+	data := testdoc.Store(byte(store.STORE), []string{"Foo.BarDesc", "Stores.StoreDesc"}, content, downOff)
+
+	s, err := NewReader(bytes.NewReader(data)).ReadStore()
+	if err != nil {
+		t.Fatalf("ReadStore failed: %v", err)
+	}
+	a := s.(*alien.Alien)
+	content0 := int64(len(data) - len(content))
+	if span := a.GetSpan(); span.Start != 0 || span.Content != content0 || span.End != int64(len(data)) ||
+		span.Header != content0-16 || span.Down != content0+3 {
+		t.Errorf("Unexpected alien span: %s", span)
+	}
+
+	comps := a.GetComponents()
+	if len(comps) != 3 {
+		t.Fatalf("Expected 3 components, got %d", len(comps))
+	}
+	if span := comps[0].(*alien.AlienPiece).GetSpan(); span.Start != content0 || span.End != content0+3 {
+		t.Errorf("Unexpected alien piece span: %s", span)
+	}
+
+	tm := comps[1].(*alien.AlienPart).GetStore().(*textmodel.StdTextModel)
+	modelStart := content0 + 3
+	linkStart := modelStart + int64(len(model))
+	if span := tm.GetSpan(); span.Start != modelStart || span.End != linkStart || span.Next != linkStart {
+		t.Errorf("Unexpected text model span: %s", span)
+	}
+	if span := tm.GetPieces()[0].GetSpan(); span.End != linkStart || span.Len() != 5 {
+		t.Errorf("Unexpected piece span: %s", span)
+	}
+
+	if comps[2].(*alien.AlienPart).GetStore() != tm {
+		t.Fatal("Expected the link to resolve to the text model")
+	}
+	links := tm.GetLinks()
+	if len(links) != 1 || links[0].Marker != store.LINK || links[0].Start != linkStart {
+		t.Errorf("Unexpected links: %v", links)
+	}
+}
+
+func TestPrimitives(t *testing.T) {
+	data := []byte{
+		1,                      // BOOLEAN
+		0x05, 0x00, 0x00, 0x80, // SET {0, 2, 31}
+		0xFE, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, // LONGINT -2
+		0x00, 0x00, 0xC0, 0x3F, // SHORTREAL 1.5
+		0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x04, 0xC0, // REAL -2.5
+		'H', 0, 0xAC, 0x20, 0x3D, 0xD8, 0x00, 0xDE, 0, 0, // String "H€😀"
+		'x', 0xC3, 0xA9, 0, // XString "xé"
+	}
+	r := NewReader(bytes.NewReader(data))
+
+	if b, err := r.ReadBool(); err != nil || !b {
+		t.Errorf("ReadBool: got %v, %v", b, err)
+	}
+	if s, err := r.ReadSet(); err != nil || s != 1<<0|1<<2|1<<31 {
+		t.Errorf("ReadSet: got %b, %v", s, err)
+	}
+	if l, err := r.ReadLong(); err != nil || l != -2 {
+		t.Errorf("ReadLong: got %d, %v", l, err)
+	}
+	if f, err := r.ReadSReal(); err != nil || f != 1.5 {
+		t.Errorf("ReadSReal: got %v, %v", f, err)
+	}
+	if f, err := r.ReadReal(); err != nil || f != -2.5 {
+		t.Errorf("ReadReal: got %v, %v", f, err)
+	}
+	if s, err := r.ReadString(); err != nil || s != "H€😀" {
+		t.Errorf("ReadString: got %q, %v", s, err)
+	}
+	if s, err := r.ReadXString(); err != nil || s != "xé" {
+		t.Errorf("ReadXString: got %q, %v", s, err)
+	}
+	if r.Pos() != int64(len(data)) {
+		t.Errorf("Expected all %d bytes consumed, got %d", len(data), r.Pos())
+	}
+
+	// Truncated values fail
+	if _, err := NewReader(bytes.NewReader(data[1:4])).ReadSet(); err == nil {
+		t.Error("ReadSet: expected error for truncated input")
+	}
+	if _, err := NewReader(bytes.NewReader([]byte{'a', 0})).ReadString(); err == nil {
+		t.Error("ReadString: expected error for unterminated string")
+	}
+	// X-strings that are not UTF-8 are Latin-1, as in older files
+	if s, err := NewReader(bytes.NewReader([]byte{'x', 0xE9, 0xFF, 0})).ReadXString(); err != nil || s != "xéÿ" {
+		t.Errorf("ReadXString: got %q, %v for Latin-1", s, err)
+	}
+}
+
+func TestSniff(t *testing.T) {
+	model := testdoc.TextModel("x")
+	withHeader := func(tag, version int32) []byte {
+		buf := new(bytes.Buffer)
+		testdoc.LE(buf, tag)
+		testdoc.LE(buf, version)
+		buf.Write(model)
+		return buf.Bytes()
+	}
+
+	tests := []struct {
+		name   string
+		data   []byte
+		kind   FileKind
+		root   int64
+		format string
+	}{
+		{"document", withHeader(int32(DocTag), 0), Document, 8, ""},
+		{"newer", withHeader(int32(DocTag), 1), NewerDocument, 8, ""},
+		{"negative version", withHeader(int32(DocTag), -1), CorruptDocument, 8, ""},
+		{"other tag", withHeader(0x12345678, 0), StoresFile, 8, ""},
+		{"no header", model, StoresFile, 0, ""},
+		{"code", withHeader(0x6F4F4346, 0), CodeFile, -1, ""},
+		{"pdf", []byte("%PDF-1.4\n..."), Foreign, -1, "PDF"},
+		{"text", []byte("MODULE Foo; END Foo."), Foreign, -1, "text"},
+		{"short", []byte{0x83, 0xF1}, Foreign, -1, ""},
+	}
+	for _, tt := range tests {
+		fi := Sniff(tt.data)
+		if fi.Kind != tt.kind || fi.Root != tt.root || fi.Format != tt.format {
+			t.Errorf("%s: got %v root %d format %q", tt.name, fi.Kind, fi.Root, fi.Format)
+		}
+	}
+	if got := Sniff(withHeader(int32(DocTag), -1)).String(); got != "BlackBox document with invalid version -1, probably corrupt" {
+		t.Errorf("Unexpected description %q", got)
+	}
+}
+
+func TestReadRoots_Trailing(t *testing.T) {
+	first, second := testdoc.TextModel("one"), testdoc.TextModel("two")
+	data := append(append(append([]byte{}, first...), second...), 0, 0, 0)
+
+	r := NewReader(bytes.NewReader(data))
+	if _, err := r.ReadStore(); err != nil {
+		t.Fatalf("ReadStore failed: %v", err)
+	}
+	if n := r.Trailing(); n != int64(len(second)+3) {
+		t.Errorf("Expected %d trailing bytes, got %d", len(second)+3, n)
+	}
+	if !r.HasNextRoot() {
+		t.Error("Expected another root after the first")
+	}
+
+	r = NewReader(bytes.NewReader(data))
+	roots, err := r.ReadRoots()
+	if err != nil {
+		t.Fatalf("ReadRoots failed: %v", err)
+	}
+	if len(roots) != 2 {
+		t.Fatalf("Expected 2 roots, got %d", len(roots))
+	}
+	sum := r.Summarize(roots...)
+	if sum.Consumed() || sum.Trailing != 3 || sum.Roots[1].Start != int64(len(first)) {
+		t.Errorf("Unexpected summary: %s", sum)
+	}
+	want := fmt.Sprintf("2 roots, %d of %d bytes read, 3 trailing bytes at offset %d", len(data)-3, len(data), len(data)-3)
+	if sum.String() != want {
+		t.Errorf("Expected %q, got %q", want, sum.String())
+	}
+}
+
+func TestReadStore_InconsistentVersion(t *testing.T) {
+	// A text model followed by a byte its Internalize does not read
+	content, downOff := testdoc.Content(
+		testdoc.Raw{0, 0, 0, 0, 0, 0}, testdoc.Raw{0, 0, 0, 0},
+		testdoc.Raw{0}, testdoc.Nil(0), testdoc.Raw{2, 0, 0, 0}, testdoc.Raw{0xFF},
+		testdoc.Raw("Hi"), testdoc.Raw{7},
+	)
+	doc := testdoc.Store(byte(store.ELEM), testdoc.TextModelPath, content, downOff)
+
+	var causes []int
+	r := NewReader(bytes.NewReader(doc))
+	r.SetTracer(TracerFunc(func(ev Event) {
+		if ev.Kind == AlienCreated {
+			causes = append(causes, ev.Cause)
+		}
+	}))
+	s, err := r.ReadStore()
+	if err != nil {
+		t.Fatalf("ReadStore failed: %v", err)
+	}
+	if _, ok := s.(*alien.Alien); !ok {
+		t.Fatalf("Expected an alien, got %s", s)
+	}
+	if len(causes) != 1 || causes[0] != InconsistentVersion {
+		t.Errorf("Expected cause %d, got %v", InconsistentVersion, causes)
+	}
+
+	// The extra byte is reported, as it may also be a decoder that misses a field
+	damages := r.Damages()
+	if len(damages) != 1 || !damages[0].Alien || damages[0].Offset != 0 || damages[0].Resume != int64(len(doc)) {
+		t.Fatalf("Expected one alien damage for the whole store, got %v", damages)
+	}
+	if got := damages[0].String(); !strings.Contains(got, "as an alien: internalize ended at") {
+		t.Errorf("Unexpected damage description %q", got)
+	}
+}
+
+// rejecting is a store whose Internalize rejects its data.
+type rejecting struct {
+	store.BaseStore
+}
+
+func (s *rejecting) GetTypeName() string { return "ReaderTest.Rejecting^" }
+
+func (s *rejecting) Internalize(reader store.Reader) error {
+	if err := s.BaseStore.Internalize(reader); err != nil {
+		return err
+	}
+	kind, err := reader.ReadByte()
+	if err != nil {
+		return err
+	}
+	return fmt.Errorf("invalid kind %d", kind)
+}
+
+func TestReadStore_InternalizeFailed(t *testing.T) {
+	typeregister.Register("ReaderTest.Rejecting^", func(id int32) store.Store {
+		return &rejecting{BaseStore: store.NewBaseStore(id)}
+	})
+	content, downOff := testdoc.Content(testdoc.Raw{0, 9})
+	view := testdoc.Store(byte(store.STORE), []string{"ReaderTest.RejectingDesc", "Stores.StoreDesc"}, content, downOff)
+	doc := testdoc.TextModel("Before ", testdoc.View{Store: view}, " after")
+
+	r := NewReader(bytes.NewReader(doc))
+	s, err := r.ReadStore()
+	if err != nil {
+		t.Fatalf("ReadStore failed: %v", err)
+	}
+	m, ok := s.(*textmodel.StdTextModel)
+	if !ok {
+		t.Fatalf("Expected a text model, got %s", s)
+	}
+	pieces := m.GetPieces()
+	if len(pieces) != 3 {
+		t.Fatalf("Expected 3 pieces, got %v", pieces)
+	}
+	if _, ok := pieces[1].(*textmodel.ViewPiece).GetView().(*alien.Alien); !ok {
+		t.Errorf("Expected the rejected view to be an alien, got %v", pieces[1])
+	}
+	before, after := pieces[0].(*textmodel.ShortPiece), pieces[2].(*textmodel.ShortPiece)
+	if got := string(before.GetBuffer()[:7]) + "|" + string(after.GetBuffer()[:6]); got != "Before | after" {
+		t.Errorf("Expected the surrounding text, got %q", got)
+	}
+
+	damages := r.Damages()
+	if len(damages) != 1 || !damages[0].Alien || damages[0].Path[0] != "ReaderTest.Rejecting^" {
+		t.Fatalf("Expected one alien damage for the rejected view, got %v", damages)
+	}
+	if got := damages[0].String(); !strings.Contains(got, "as an alien: failed to internalize ReaderTest.Rejecting^: invalid kind 9") {
+		t.Errorf("Unexpected damage description %q", got)
+	}
+}
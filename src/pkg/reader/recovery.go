@@ -0,0 +1,101 @@
+package reader
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+
+	"odcread/pkg/alien"
+	"odcread/pkg/oberon"
+	"odcread/pkg/store"
+)
+
+// Damage describes a region of the input that was skipped in lenient mode,
+// or a store of a registered type that was read as an alien because its
+// Internalize rejected its data or did not end at the end of the store.
+type Damage struct {
+	Offset int64          // Position of the damaged store's marker
+	Resume int64          // Position at which parsing resumed
+	Path   store.TypePath // Type path of the damaged store
+	Err    error          // What went wrong
+	Alien  bool           // The store was read as an alien rather than skipped
+}
+
+// String returns a one-line description of the damage.
+func (d Damage) String() string {
+	if d.Alien {
+		return fmt.Sprintf("read %s at [%d, %d) as an alien: %v", d.Path.String(), d.Offset, d.Resume, d.Err)
+	}
+	return fmt.Sprintf("skipped %s at [%d, %d): %v", d.Path.String(), d.Offset, d.Resume, d.Err)
+}
+
+// SetLenient enables or disables lenient mode. In lenient mode a store that
+// cannot be read is replaced by an alien.Damaged placeholder holding whatever
+// was read, and parsing resumes at the end of the store given by its header.
+func (r *Reader) SetLenient(lenient bool) {
+	r.lenient = lenient
+}
+
+// Damages returns the regions skipped so far in lenient mode and the
+// stores read as aliens because their data was rejected or they did not
+// end where expected.
+func (r *Reader) Damages() []Damage {
+	return r.damages
+}
+
+// isFormatError reports whether err, returned by Internalize, rejects the
+// data of a store rather than reporting a failing or truncated input, an
+// exceeded limit or a canceled parse. A store whose data is rejected is
+// read as an alien instead.
+func isFormatError(err error) bool {
+	var pathErr *fs.PathError
+	return err != nil && !errors.Is(err, io.EOF) && !errors.Is(err, io.ErrUnexpectedEOF) &&
+		!errors.As(err, &pathErr) && !errors.Is(err, ErrLimitExceeded) && !isContextError(err)
+}
+
+// intact reports whether a store ending at end lies within the input and
+// no store inside it was skipped since m, so that it can be read again as
+// an alien.
+func (r *Reader) intact(m dictMark, end int64) bool {
+	if r.size >= 0 && end > r.size {
+		return false
+	}
+	for _, d := range r.damages[m.damages:] {
+		if !d.Alien {
+			return false
+		}
+	}
+	return true
+}
+
+// salvage replaces a store that failed to read with a placeholder and moves
+// to the end of the store, clamped to the input size.
+// It returns the original error if the reader is not lenient, a limit was
+// exceeded or the parse was canceled.
+func (r *Reader) salvage(isElem bool, id oberon.Integer, path store.TypePath,
+	span store.Span, partial store.Store, cause error) (store.Store, error) {
+	if !r.lenient || errors.Is(cause, ErrLimitExceeded) || isContextError(cause) {
+		return nil, cause
+	}
+
+	start, resume := span.Start, span.End
+	if r.size >= 0 && resume > r.size {
+		resume = r.size
+	}
+	if _, err := r.rider.Seek(resume, io.SeekStart); err != nil {
+		return nil, cause
+	}
+
+	d := alien.NewDamaged(id, path, partial, cause)
+	d.SetSpan(span)
+	r.damages = append(r.damages, Damage{Offset: start, Resume: resume, Path: path, Err: cause})
+
+	// Take the place reserved for the store in its dictionary
+	r.register(isElem, id, start, d)
+
+	r.cause = 0
+	r.cancelled = false
+	r.trace(Event{Kind: StoreEnd, Offset: resume, ID: id, Path: path, Store: d})
+	return d, nil
+}
@@ -0,0 +1,101 @@
+package reader
+
+import (
+	"fmt"
+	"strings"
+
+	"odcread/pkg/store"
+)
+
+// Trailing returns the number of input bytes after the current position,
+// or -1 if the input size is unknown.
+func (r *Reader) Trailing() int64 {
+	if r.size < 0 {
+		return -1
+	}
+	if n := r.size - r.Pos(); n > 0 {
+		return n
+	}
+	return 0
+}
+
+// HasNextRoot reports whether the bytes at the current position begin
+// another store, as written by tools that append stores to a document.
+func (r *Reader) HasNextRoot() bool {
+	var b [2]byte
+	if n, _ := r.rider.ReadAt(b[:], r.Pos()); n < len(b) {
+		return false
+	}
+	if b[0] != store.STORE && b[0] != store.ELEM {
+		return false
+	}
+	return b[1] == store.NEWBASE || b[1] == store.NEWEXT || b[1] == store.OLDTYPE
+}
+
+// ReadRoots reads the store at the current position and every further
+// root that follows it. Further roots share the type and store
+// dictionaries of the first one, so their OLDTYPE and LINK references
+// resolve as if the stores had been written by a single Stores.Writer.
+// If a further root cannot be read, the roots read so far are returned
+// with the error.
+func (r *Reader) ReadRoots() ([]store.Store, error) {
+	root, err := r.ReadStore()
+	if err != nil {
+		return nil, err
+	}
+	roots := []store.Store{root}
+	for r.HasNextRoot() {
+		start := r.Pos()
+		s, err := r.ReadStore()
+		if err != nil {
+			return roots, fmt.Errorf("failed to read root %d at offset %d: %w", len(roots)+1, start, err)
+		}
+		roots = append(roots, s)
+	}
+	return roots, nil
+}
+
+// Summary describes how much of the input the roots cover.
+type Summary struct {
+	Size     int64        // Input size, or -1 if unknown
+	Roots    []store.Span // Spans of the roots, in file order
+	End      int64        // Offset after the last root
+	Trailing int64        // Bytes after End, or -1 if the size is unknown
+}
+
+// Summarize returns the summary for roots read from r, with the reader
+// positioned after the last of them.
+func (r *Reader) Summarize(roots ...store.Store) Summary {
+	sum := Summary{Size: r.size, End: r.Pos(), Trailing: r.Trailing()}
+	for _, s := range roots {
+		if s != nil {
+			sum.Roots = append(sum.Roots, s.GetSpan())
+		}
+	}
+	return sum
+}
+
+// Consumed reports whether the roots extend to the end of the input.
+func (s Summary) Consumed() bool {
+	return s.Trailing == 0
+}
+
+// String returns a description such as "1 root, 1234 of 1240 bytes read,
+// 6 trailing bytes at offset 1234".
+func (s Summary) String() string {
+	var sb strings.Builder
+	if len(s.Roots) == 1 {
+		sb.WriteString("1 root")
+	} else {
+		fmt.Fprintf(&sb, "%d roots", len(s.Roots))
+	}
+	switch {
+	case s.Size < 0:
+		fmt.Fprintf(&sb, ", %d bytes read, input size unknown", s.End)
+	case s.Consumed():
+		fmt.Fprintf(&sb, ", %d bytes read, file fully consumed", s.End)
+	default:
+		fmt.Fprintf(&sb, ", %d of %d bytes read, %d trailing bytes at offset %d", s.End, s.Size, s.Trailing, s.End)
+	}
+	return sb.String()
+}
@@ -0,0 +1,186 @@
+package reader
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"unicode/utf8"
+
+	"odcread/pkg/oberon"
+	"odcread/pkg/store"
+)
+
+// Document file header (Documents.Mod)
+const (
+	DocTag     = oberon.Integer(0x6F4F4443) // "CDOo"
+	DocVersion = oberon.Integer(0)
+)
+
+// Tags of other BlackBox files (Kernel.Mod); they do not contain stores.
+const (
+	codeTag   = oberon.Integer(0x6F4F4346) // "FCOo"
+	symbolTag = oberon.Integer(0x6F4F5346) // "FSOo"
+)
+
+// SniffLen is the number of leading bytes Sniff needs to classify a file.
+const SniffLen = 512
+
+// FileKind classifies a file by its leading bytes.
+type FileKind int
+
+const (
+	Foreign         FileKind = iota // Not a BlackBox file
+	Document                        // A BlackBox document (.odc) this reader supports
+	NewerDocument                   // A BlackBox document with a newer, unsupported version
+	StoresFile                      // Stores written with a tag other than the document tag
+	CodeFile                        // A BlackBox code file (.ocf)
+	SymbolFile                      // A BlackBox symbol file (.osf)
+	CorruptDocument                 // A BlackBox document tag with an invalid version
+)
+
+// String returns a description of the file kind.
+func (k FileKind) String() string {
+	switch k {
+	case Foreign:
+		return "foreign file"
+	case Document:
+		return "BlackBox document"
+	case NewerDocument:
+		return "BlackBox document (unsupported version)"
+	case StoresFile:
+		return "BlackBox Stores file"
+	case CodeFile:
+		return "BlackBox code file"
+	case SymbolFile:
+		return "BlackBox symbol file"
+	case CorruptDocument:
+		return "BlackBox document (invalid version)"
+	default:
+		return fmt.Sprintf("FileKind(%d)", int(k))
+	}
+}
+
+// FileInfo is the result of Sniff.
+type FileInfo struct {
+	Kind    FileKind
+	Tag     oberon.Integer // First 4 bytes, little-endian
+	Version oberon.Integer // Next 4 bytes, little-endian
+	Root    int64          // Offset of the root store, or -1 if none was found
+	Format  string         // Recognized foreign format (e.g. "PDF"), or ""
+}
+
+// String returns a one-line description of the file.
+func (fi FileInfo) String() string {
+	switch fi.Kind {
+	case Document:
+		return fi.Kind.String()
+	case NewerDocument:
+		return fmt.Sprintf("BlackBox document version %d (only version %d is supported)", fi.Version, DocVersion)
+	case CorruptDocument:
+		return fmt.Sprintf("BlackBox document with invalid version %d, probably corrupt", fi.Version)
+	case StoresFile:
+		if fi.Root == 0 {
+			return "BlackBox stores without a file header, not a document"
+		}
+		return fmt.Sprintf("BlackBox Stores file with tag 0x%08X, not a document", uint32(fi.Tag))
+	case Foreign:
+		if fi.Format != "" {
+			return fmt.Sprintf("not a BlackBox file (looks like %s)", fi.Format)
+		}
+		return "not a BlackBox file"
+	default:
+		return fi.Kind.String()
+	}
+}
+
+// magics maps the leading bytes of common formats to their names.
+var magics = []struct {
+	prefix string
+	format string
+}{
+	{"%PDF", "PDF"},
+	{"PK\x03\x04", "a ZIP archive"},
+	{"\x89PNG", "PNG"},
+	{"GIF8", "GIF"},
+	{"\xFF\xD8\xFF", "JPEG"},
+	{"BM", "BMP"},
+	{"MZ", "a Windows executable"},
+	{"\x7FELF", "an ELF executable"},
+	{"{\\rtf", "RTF"},
+	{"\xD0\xCF\x11\xE0", "an OLE compound file"},
+}
+
+// Sniff classifies a file from its first bytes (up to SniffLen).
+// Files whose root store does not directly follow the 8-byte header are
+// classified by looking for a well-formed store at the start of the file.
+func Sniff(header []byte) FileInfo {
+	fi := FileInfo{Kind: Foreign, Root: -1}
+	if len(header) >= 8 {
+		fi.Tag = oberon.Integer(binary.LittleEndian.Uint32(header))
+		fi.Version = oberon.Integer(binary.LittleEndian.Uint32(header[4:]))
+	}
+
+	switch {
+	case len(header) < 8:
+	case fi.Tag == DocTag && fi.Version == DocVersion:
+		fi.Kind, fi.Root = Document, 8
+		return fi
+	case fi.Tag == DocTag && fi.Version > DocVersion:
+		fi.Kind, fi.Root = NewerDocument, 8
+		return fi
+	case fi.Tag == DocTag:
+		fi.Kind, fi.Root = CorruptDocument, 8
+		return fi
+	case fi.Tag == codeTag:
+		fi.Kind = CodeFile
+		return fi
+	case fi.Tag == symbolTag:
+		fi.Kind = SymbolFile
+		return fi
+	case looksLikeStore(header[8:]):
+		fi.Kind, fi.Root = StoresFile, 8
+		return fi
+	}
+	if looksLikeStore(header) {
+		fi.Kind, fi.Root = StoresFile, 0
+		return fi
+	}
+
+	for _, m := range magics {
+		if bytes.HasPrefix(header, []byte(m.prefix)) {
+			fi.Format = m.format
+			return fi
+		}
+	}
+	if len(header) > 0 && utf8.Valid(header) && bytes.IndexByte(header, 0) < 0 {
+		fi.Format = "text"
+	}
+	return fi
+}
+
+// looksLikeStore reports whether b starts with a STORE or ELEM whose type
+// path begins with a plausible type name such as "TextViews.StdViewDesc".
+func looksLikeStore(b []byte) bool {
+	if len(b) < 3 || (b[0] != store.STORE && b[0] != store.ELEM) {
+		return false
+	}
+	if b[1] != store.NEWBASE && b[1] != store.NEWEXT {
+		return false
+	}
+	end := bytes.IndexByte(b[2:], 0)
+	if end < 3 {
+		return false
+	}
+	name := b[2 : 2+end]
+	dot := false
+	for _, c := range name {
+		switch {
+		case c == '.':
+			dot = true
+		case c >= 'A' && c <= 'Z', c >= 'a' && c <= 'z', c >= '0' && c <= '9', c == '_':
+		default:
+			return false
+		}
+	}
+	return dot
+}
@@ -0,0 +1,114 @@
+package reader
+
+import (
+	"fmt"
+
+	"odcread/pkg/oberon"
+	"odcread/pkg/store"
+)
+
+// EventKind identifies the kind of a parse event reported to a Tracer.
+type EventKind int
+
+const (
+	StoreBegin      EventKind = iota // A STORE or ELEM marker has been read
+	StoreEnd                         // A store has been completely read
+	TypeResolved                     // A type path has been read and looked up in the registry
+	AlienCreated                     // A store is being read as an alien
+	VersionMismatch                  // A version byte was outside the accepted range
+	LinkResolved                     // A LINK or NEWLINK marker was resolved to an earlier store
+)
+
+// String returns the name of the event kind.
+func (k EventKind) String() string {
+	switch k {
+	case StoreBegin:
+		return "StoreBegin"
+	case StoreEnd:
+		return "StoreEnd"
+	case TypeResolved:
+		return "TypeResolved"
+	case AlienCreated:
+		return "AlienCreated"
+	case VersionMismatch:
+		return "VersionMismatch"
+	case LinkResolved:
+		return "LinkResolved"
+	default:
+		return fmt.Sprintf("EventKind(%d)", int(k))
+	}
+}
+
+// Event describes a single step of the parse.
+// Only the fields relevant to the event kind are set.
+type Event struct {
+	Kind   EventKind
+	Offset int64       // Input position the event refers to
+	Depth  int         // Store nesting depth (1 for the root store)
+	State  ReaderState // Reader state at the time of the event
+
+	Marker     oberon.ShortChar // Store marker (StoreBegin, LinkResolved)
+	ID         oberon.Integer   // Store or link ID
+	Path       store.TypePath   // Type path of the store
+	Store      store.Store      // Resulting store (StoreEnd, LinkResolved)
+	Registered bool             // Whether the type is registered (TypeResolved)
+	Cause      int              // Alien cause code (AlienCreated)
+	Version    oberon.Integer   // Version read (VersionMismatch)
+	Min, Max   oberon.Integer   // Accepted version range (VersionMismatch)
+}
+
+// String returns a one-line description of the event.
+func (e Event) String() string {
+	prefix := fmt.Sprintf("@%d %s", e.Offset, e.Kind)
+	switch e.Kind {
+	case StoreBegin:
+		return fmt.Sprintf("%s marker=0x%X id=%d", prefix, e.Marker, e.ID)
+	case StoreEnd:
+		if e.Store != nil {
+			return fmt.Sprintf("%s %s next=%d end=%d", prefix, e.Store.String(), e.State.Next, e.State.End)
+		}
+		return fmt.Sprintf("%s <nil> next=%d end=%d", prefix, e.State.Next, e.State.End)
+	case TypeResolved:
+		return fmt.Sprintf("%s %s registered=%v", prefix, e.Path.String(), e.Registered)
+	case AlienCreated:
+		return fmt.Sprintf("%s %s cause=%d", prefix, e.Path.String(), e.Cause)
+	case VersionMismatch:
+		return fmt.Sprintf("%s version=%d range=[%d, %d]", prefix, e.Version, e.Min, e.Max)
+	case LinkResolved:
+		target := "<nil>"
+		if e.Store != nil {
+			target = e.Store.String()
+		}
+		return fmt.Sprintf("%s marker=0x%X id=%d -> %s", prefix, e.Marker, e.ID, target)
+	default:
+		return prefix
+	}
+}
+
+// Tracer receives parse events from a Reader.
+type Tracer interface {
+	Trace(ev Event)
+}
+
+// TracerFunc adapts an ordinary function to the Tracer interface.
+type TracerFunc func(ev Event)
+
+// Trace calls f(ev).
+func (f TracerFunc) Trace(ev Event) {
+	f(ev)
+}
+
+// SetTracer installs a tracer that receives parse events. A nil tracer disables tracing.
+func (r *Reader) SetTracer(t Tracer) {
+	r.tracer = t
+}
+
+// trace fills in the common event fields and reports the event to the tracer, if any.
+func (r *Reader) trace(ev Event) {
+	if r.tracer == nil {
+		return
+	}
+	ev.Depth = r.depth
+	ev.State = *r.state
+	r.tracer.Trace(ev)
+}
@@ -0,0 +1,337 @@
+// Package ruler provides text rulers, the views that set the paragraph
+// format (margins, tabs, alignment and spacing) of the text following them.
+package ruler
+
+import (
+	"fmt"
+	"strings"
+
+	"odcread/pkg/alien"
+	"odcread/pkg/fold"
+	"odcread/pkg/oberon"
+	"odcread/pkg/store"
+)
+
+const (
+	TypeNameAttributes = "TextRulers.Attributes^"
+	TypeNameStdStyle   = "TextRulers.StdStyle^"
+	TypeNameStdRuler   = "TextRulers.StdRuler^"
+)
+
+// Paragraph options (Attributes.opts)
+const (
+	LeftAdjust    = 0  // Align left; with RightAdjust, justify
+	RightAdjust   = 1  // Align right; with LeftAdjust, justify
+	NoBreakInside = 2  // Keep the paragraph on one page
+	PageBreak     = 3  // Start the paragraph on a new page
+	ParJoin       = 4  // Keep the paragraph on the same page as the next one
+	RightFixed    = 31 // The right margin is fixed, not relative to the view width
+)
+
+// Tab types (Tab.type)
+const (
+	CenterTab = 0 // Center the text at the tab stop
+	RightTab  = 1 // Align the text's right end at the tab stop
+	BarTab    = 2 // Draw a vertical bar at the tab stop
+)
+
+// maxTabs is the maximum number of tab stops of a ruler.
+const maxTabs = 32
+
+// Alignment is the horizontal alignment of a paragraph.
+type Alignment int
+
+const (
+	Left Alignment = iota
+	Right
+	Center
+	Justified
+)
+
+// String returns the name of the alignment.
+func (a Alignment) String() string {
+	switch a {
+	case Left:
+		return "left"
+	case Right:
+		return "right"
+	case Center:
+		return "center"
+	case Justified:
+		return "justified"
+	default:
+		return fmt.Sprintf("Alignment(%d)", int(a))
+	}
+}
+
+// Tab is a tab stop.
+type Tab struct {
+	Stop oberon.Integer // Position in universal units from the left margin
+	Type oberon.Set
+}
+
+// Align returns the alignment of the text at the tab stop: Left, Right or Center.
+func (t Tab) Align() Alignment {
+	switch {
+	case t.Type&(1<<CenterTab) != 0:
+		return Center
+	case t.Type&(1<<RightTab) != 0:
+		return Right
+	default:
+		return Left
+	}
+}
+
+// Bar returns whether a vertical bar is drawn at the tab stop.
+func (t Tab) Bar() bool {
+	return t.Type&(1<<BarTab) != 0
+}
+
+// String returns a description such as "12.0mm right".
+func (t Tab) String() string {
+	s := fmt.Sprintf("%.1fmm %s", float64(t.Stop)/36000, t.Align())
+	if t.Bar() {
+		s += " bar"
+	}
+	return s
+}
+
+// Attributes is the paragraph format of a ruler (TextRulers.Attributes).
+// Lengths are in universal units (1/36000 mm).
+type Attributes struct {
+	store.BaseStore
+	First, Left, Right oberon.Integer // First-line, left and right margins
+	Lead               oberon.Integer // Space above the paragraph
+	Asc, Dsc           oberon.Integer // Minimum ascender and descender of lines
+	Grid               oberon.Integer // Line grid; line heights are rounded up to it
+	Opts               oberon.Set
+	Tabs               []Tab
+}
+
+// NewAttributes creates a new Attributes instance.
+func NewAttributes(id oberon.Integer) *Attributes {
+	return &Attributes{
+		BaseStore: store.NewBaseStore(id),
+	}
+}
+
+// GetTypeName returns the type name for Attributes.
+func (a *Attributes) GetTypeName() string {
+	return TypeNameAttributes
+}
+
+// Internalize reads Attributes data from the reader.
+// Format (TextRulers.Attributes.Internalize):
+//
+//	version (0..2)
+//	first, left, right, lead, asc, dsc, grid (int)
+//	opts (set)
+//	number of tabs (sint)
+//	tab stops (int), then, from version 1 on, tab types (set)
+func (a *Attributes) Internalize(reader store.Reader) error {
+	if err := a.BaseStore.Internalize(reader); err != nil {
+		return err
+	}
+	version, err := reader.ReadVersion(0, 2)
+	if err != nil {
+		return err
+	}
+
+	for _, p := range []*oberon.Integer{&a.First, &a.Left, &a.Right, &a.Lead, &a.Asc, &a.Dsc, &a.Grid} {
+		if *p, err = reader.ReadInt(); err != nil {
+			return fmt.Errorf("failed to read ruler attributes: %w", err)
+		}
+	}
+	if a.Opts, err = reader.ReadSet(); err != nil {
+		return fmt.Errorf("failed to read ruler options: %w", err)
+	}
+
+	n, err := reader.ReadSInt()
+	if err != nil {
+		return fmt.Errorf("failed to read number of tabs: %w", err)
+	}
+	if n < 0 || n > maxTabs {
+		return fmt.Errorf("invalid number of tabs %d", n)
+	}
+	a.Tabs = make([]Tab, n)
+	for i := range a.Tabs {
+		if a.Tabs[i].Stop, err = reader.ReadInt(); err != nil {
+			return fmt.Errorf("failed to read tab stop: %w", err)
+		}
+	}
+	if version >= 1 {
+		for i := range a.Tabs {
+			if a.Tabs[i].Type, err = reader.ReadSet(); err != nil {
+				return fmt.Errorf("failed to read tab type: %w", err)
+			}
+		}
+	}
+	return nil
+}
+
+// Has returns whether option opt (e.g. PageBreak) is set.
+func (a *Attributes) Has(opt int) bool {
+	return a.Opts&(1<<uint(opt)) != 0
+}
+
+// Alignment returns the paragraph alignment.
+func (a *Attributes) Alignment() Alignment {
+	switch left, right := a.Has(LeftAdjust), a.Has(RightAdjust); {
+	case left && right:
+		return Justified
+	case left:
+		return Left
+	case right:
+		return Right
+	default:
+		return Center
+	}
+}
+
+// String returns a description of the paragraph format.
+func (a *Attributes) String() string {
+	tabs := make([]string, len(a.Tabs))
+	for i, t := range a.Tabs {
+		tabs[i] = t.String()
+	}
+	s := fmt.Sprintf("Attributes{id: %d, %s, first: %d, left: %d, right: %d, tabs: [%s]",
+		a.GetID(), a.Alignment(), a.First, a.Left, a.Right, strings.Join(tabs, ", "))
+	if a.Has(PageBreak) {
+		s += ", page break"
+	}
+	return s + "}"
+}
+
+// StdStyle is the model of a ruler (TextRulers.StdStyle). Rulers may
+// share a style, so that changing one changes all of them.
+type StdStyle struct {
+	store.Model
+	attr store.Store
+}
+
+// NewStdStyle creates a new StdStyle instance.
+func NewStdStyle(id oberon.Integer) *StdStyle {
+	return &StdStyle{
+		Model: *store.NewModel(id),
+	}
+}
+
+// GetTypeName returns the type name for StdStyle.
+func (s *StdStyle) GetTypeName() string {
+	return TypeNameStdStyle
+}
+
+// Internalize reads StdStyle data from the reader.
+// Format (TextRulers.Style and StdStyle.Internalize):
+//
+//	version (0)
+//	attributes (store)
+//	version (0)
+func (s *StdStyle) Internalize(reader store.Reader) error {
+	if err := s.Model.Internalize(reader); err != nil {
+		return err
+	}
+	if _, err := reader.ReadVersion(0, 0); err != nil {
+		return err
+	}
+	attr, err := reader.ReadStore()
+	if err != nil {
+		return fmt.Errorf("failed to read style attributes: %w", err)
+	}
+	if _, ok := attr.(*alien.Alien); ok || attr == nil {
+		return reader.TurnIntoAlien(store.AlienComponent)
+	}
+	s.attr = attr
+	_, err = reader.ReadVersion(0, 0)
+	return err
+}
+
+// String returns a string representation of the StdStyle.
+func (s *StdStyle) String() string {
+	return fmt.Sprintf("StdStyle{id: %d}", s.GetID())
+}
+
+// GetAttributes returns the paragraph format, or nil if it has not been loaded.
+func (s *StdStyle) GetAttributes() *Attributes {
+	a, _ := s.attr.(*Attributes)
+	return a
+}
+
+// Children returns the attributes.
+func (s *StdStyle) Children() []store.Store {
+	if s.attr == nil {
+		return nil
+	}
+	return []store.Store{s.attr}
+}
+
+// StdRuler is the standard ruler view (TextRulers.StdRuler).
+type StdRuler struct {
+	fold.View
+	style store.Store
+}
+
+// NewStdRuler creates a new StdRuler instance.
+func NewStdRuler(id oberon.Integer) *StdRuler {
+	return &StdRuler{
+		View: *fold.NewView(id),
+	}
+}
+
+// GetTypeName returns the type name for StdRuler.
+func (r *StdRuler) GetTypeName() string {
+	return TypeNameStdRuler
+}
+
+// Internalize reads StdRuler data from the reader.
+// Format (TextRulers.Ruler and StdRuler.Internalize):
+//
+//	version (0)
+//	style (store)
+//	version (0)
+func (r *StdRuler) Internalize(reader store.Reader) error {
+	if err := r.View.Internalize(reader); err != nil {
+		return err
+	}
+	if _, err := reader.ReadVersion(0, 0); err != nil {
+		return err
+	}
+	style, err := reader.ReadStore()
+	if err != nil {
+		return fmt.Errorf("failed to read ruler style: %w", err)
+	}
+	if _, ok := style.(*alien.Alien); ok || style == nil {
+		return reader.TurnIntoAlien(store.AlienComponent)
+	}
+	r.style = style
+	_, err = reader.ReadVersion(0, 0)
+	return err
+}
+
+// String returns a string representation of the StdRuler.
+func (r *StdRuler) String() string {
+	return fmt.Sprintf("StdRuler{id: %d}", r.GetID())
+}
+
+// GetStyle returns the ruler's style, or nil if it has not been loaded.
+func (r *StdRuler) GetStyle() *StdStyle {
+	s, _ := r.style.(*StdStyle)
+	return s
+}
+
+// GetAttributes returns the paragraph format set by the ruler, or nil if
+// it has not been loaded.
+func (r *StdRuler) GetAttributes() *Attributes {
+	if s := r.GetStyle(); s != nil {
+		return s.GetAttributes()
+	}
+	return nil
+}
+
+// Children returns the style.
+func (r *StdRuler) Children() []store.Store {
+	if r.style == nil {
+		return nil
+	}
+	return []store.Store{r.style}
+}
@@ -0,0 +1,45 @@
+package ruler_test
+
+import (
+	"testing"
+
+	"odcread/internal/testdoc"
+	"odcread/internal/testdoc/load"
+	"odcread/pkg/ruler"
+	_ "odcread/pkg/typeregister" // Import for side-effect (type registration)
+	"odcread/pkg/visitor"
+)
+
+func TestStdRuler(t *testing.T) {
+	const mm = 36000
+	r := testdoc.Ruler(5*mm, 10*mm, 150*mm, 1<<ruler.LeftAdjust|1<<ruler.RightAdjust|1<<ruler.PageBreak,
+		testdoc.RulerTab{Stop: 20 * mm, Type: 1 << ruler.RightTab},
+		testdoc.RulerTab{Stop: 40 * mm, Type: 1<<ruler.CenterTab | 1<<ruler.BarTab})
+	doc := testdoc.TextModel("Title\r", testdoc.View{Store: r}, "a\tb")
+
+	s := load.Store(t, doc)
+	rulers := visitor.Find(s, visitor.Type(ruler.TypeNameStdRuler))
+	if len(rulers) != 1 {
+		t.Fatalf("Expected 1 ruler, got %d", len(rulers))
+	}
+
+	a := rulers[0].Store().(*ruler.StdRuler).GetAttributes()
+	if a == nil {
+		t.Fatal("Expected the ruler's attributes")
+	}
+	if a.Alignment() != ruler.Justified || !a.Has(ruler.PageBreak) || a.Left != 10*mm || a.First != 5*mm {
+		t.Errorf("Unexpected attributes %s", a)
+	}
+	if len(a.Tabs) != 2 || a.Tabs[0].Align() != ruler.Right || a.Tabs[1].Align() != ruler.Center || !a.Tabs[1].Bar() {
+		t.Errorf("Unexpected tabs %v", a.Tabs)
+	}
+}
+
+// TestStdRuler_Corpus checks the rulers of the documents written by BlackBox.
+func TestStdRuler_Corpus(t *testing.T) {
+	for _, s := range load.Corpus(t, ruler.TypeNameStdRuler) {
+		if a := s.(*ruler.StdRuler).GetAttributes(); a == nil {
+			t.Error("Expected the ruler's attributes")
+		}
+	}
+}
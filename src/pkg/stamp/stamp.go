@@ -0,0 +1,132 @@
+// Package stamp provides date stamps, which show when a document was
+// last saved and keep a history of earlier saves.
+package stamp
+
+import (
+	"fmt"
+	"time"
+
+	"odcread/pkg/fold"
+	"odcread/pkg/oberon"
+	"odcread/pkg/store"
+)
+
+const TypeNameStamp = "StdStamps.StdView^"
+
+// maxHistoryEntries is the maximum number of entries in a stamp's history.
+const maxHistoryEntries = 25
+
+// Entry is a save recorded by a stamp.
+type Entry struct {
+	Fprint  oberon.Integer // Fingerprint of the document text
+	Snr     oberon.Integer // Sequence number
+	Date    time.Time      // Date and time (to the minute) of the save
+	Comment string
+}
+
+// Stamp is a date stamp (StdStamps.StdView).
+type Stamp struct {
+	fold.View
+	history []Entry // Newest entry first
+}
+
+// NewStamp creates a new Stamp instance.
+func NewStamp(id oberon.Integer) *Stamp {
+	return &Stamp{
+		View: *fold.NewView(id),
+	}
+}
+
+// GetTypeName returns the type name for Stamp.
+func (s *Stamp) GetTypeName() string {
+	return TypeNameStamp
+}
+
+// Internalize reads Stamp data from the reader.
+// Format (StdStamps.StdView.Internalize):
+//
+//	version (1..2)
+//	number of entries (int), then for each entry:
+//	  fingerprint (int), sequence number (sint in version 1, int in version 2),
+//	  date (int, days since 1 Jan 1), time (int, minute + 64 * hour),
+//	  comment (xstring)
+func (s *Stamp) Internalize(reader store.Reader) error {
+	if err := s.View.Internalize(reader); err != nil {
+		return err
+	}
+	// Version 0 stamps, which held a single date, are read as aliens
+	version, err := reader.ReadVersion(1, 2)
+	if err != nil {
+		return err
+	}
+
+	n, err := reader.ReadInt()
+	if err != nil {
+		return fmt.Errorf("failed to read stamp history length: %w", err)
+	}
+	if n < 0 || n > maxHistoryEntries {
+		return fmt.Errorf("invalid stamp history length %d", n)
+	}
+	s.history = make([]Entry, n)
+	for i := range s.history {
+		e := &s.history[i]
+		if e.Fprint, err = reader.ReadInt(); err != nil {
+			return fmt.Errorf("failed to read stamp fingerprint: %w", err)
+		}
+		if version > 1 {
+			e.Snr, err = reader.ReadInt()
+		} else {
+			var snr oberon.ShortInt
+			snr, err = reader.ReadSInt()
+			e.Snr = oberon.Integer(snr)
+		}
+		if err != nil {
+			return fmt.Errorf("failed to read stamp sequence number: %w", err)
+		}
+		day, err := reader.ReadInt()
+		if err != nil {
+			return fmt.Errorf("failed to read stamp date: %w", err)
+		}
+		t, err := reader.ReadInt()
+		if err != nil {
+			return fmt.Errorf("failed to read stamp time: %w", err)
+		}
+		e.Date = date(day, t)
+		if e.Comment, err = reader.ReadXString(); err != nil {
+			return fmt.Errorf("failed to read stamp comment: %w", err)
+		}
+	}
+	return nil
+}
+
+// date converts a day number (days since 1 Jan 1) and a time
+// (minute + 64 * hour) to a time.Time.
+func date(day, t oberon.Integer) time.Time {
+	return time.Date(1, 1, 1+int(day), int(t/64), int(t%64), 0, 0, time.UTC)
+}
+
+// String returns a string representation of the Stamp.
+func (s *Stamp) String() string {
+	return fmt.Sprintf("Stamp{id: %d, date: %s, entries: %d}", s.GetID(), s.InlineText(), len(s.history))
+}
+
+// GetHistory returns the recorded saves, newest first.
+func (s *Stamp) GetHistory() []Entry {
+	return s.history
+}
+
+// GetDate returns the date of the last save, or the zero time if there is none.
+func (s *Stamp) GetDate() time.Time {
+	if len(s.history) == 0 {
+		return time.Time{}
+	}
+	return s.history[0].Date
+}
+
+// InlineText returns the date of the last save, e.g. "2006-01-02".
+func (s *Stamp) InlineText() string {
+	if len(s.history) == 0 {
+		return ""
+	}
+	return s.GetDate().Format("2006-01-02")
+}
@@ -0,0 +1,51 @@
+package stamp_test
+
+import (
+	"testing"
+	"time"
+
+	"odcread/internal/testdoc"
+	"odcread/internal/testdoc/load"
+	"odcread/pkg/extract"
+	"odcread/pkg/stamp"
+	_ "odcread/pkg/typeregister" // Import for side-effect (type registration)
+)
+
+func TestStamp(t *testing.T) {
+	day := func(y int, m time.Month, d int) int32 {
+		secs := time.Date(y, m, d, 0, 0, 0, 0, time.UTC).Unix() - time.Date(1, 1, 1, 0, 0, 0, 0, time.UTC).Unix()
+		return int32(secs / 86400)
+	}
+	view := testdoc.Stamp(
+		testdoc.StampEntry{Day: day(2004, 3, 17), Time: 14*64 + 5, Comment: "release"},
+		testdoc.StampEntry{Day: day(2003, 12, 1), Time: 9 * 64},
+	)
+	doc := testdoc.TextModel("Saved ", testdoc.View{Store: view}, ".")
+	s := load.Store(t, doc)
+
+	st, ok := load.View(t, s, 1).(*stamp.Stamp)
+	if !ok {
+		t.Fatalf("Expected a stamp, got %v", s)
+	}
+	h := st.GetHistory()
+	if len(h) != 2 || h[0].Comment != "release" || h[1].Snr != 1 {
+		t.Fatalf("Unexpected history %+v", h)
+	}
+	if want := time.Date(2004, 3, 17, 14, 5, 0, 0, time.UTC); !st.GetDate().Equal(want) {
+		t.Errorf("Expected date %v, got %v", want, st.GetDate())
+	}
+
+	if got := extract.Extract(s).Text; got != "Saved 2004-03-17." {
+		t.Errorf("Expected %q, got %q", "Saved 2004-03-17.", got)
+	}
+}
+
+// TestStamp_Corpus checks the stamps of the documents written by BlackBox.
+// Version 0 stamps are read as aliens and not returned by the corpus.
+func TestStamp_Corpus(t *testing.T) {
+	for _, s := range load.Corpus(t, stamp.TypeNameStamp) {
+		if st := s.(*stamp.Stamp); len(st.GetHistory()) > 0 && st.GetDate().IsZero() {
+			t.Errorf("Expected the date of the last save, got %s", st)
+		}
+	}
+}
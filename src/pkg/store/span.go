@@ -0,0 +1,37 @@
+package store
+
+import (
+	"fmt"
+
+	"odcread/pkg/oberon"
+)
+
+// Span locates a store, a link or a piece of data in the input.
+// Positions are byte offsets from the start of the input; 0 means "none"
+// for Next and Down.
+type Span struct {
+	Marker  oberon.ShortChar // STORE, ELEM, LINK or NEWLINK; 0 for pieces
+	Start   int64            // Position of the marker (of the data for pieces)
+	Header  int64            // Position of the header fields after the type path
+	Content int64            // First content byte
+	End     int64            // Position just after the content
+	Next    int64            // Position of the next store in the chain, or 0
+	Down    int64            // Position of the first embedded store, or 0
+}
+
+// Len returns the length of the content in bytes.
+func (s Span) Len() int64 {
+	return s.End - s.Content
+}
+
+// String returns a description such as "[120, 480) content 151".
+func (s Span) String() string {
+	str := fmt.Sprintf("[%d, %d) content %d", s.Start, s.End, s.Content)
+	if s.Next > 0 {
+		str += fmt.Sprintf(" next %d", s.Next)
+	}
+	if s.Down > 0 {
+		str += fmt.Sprintf(" down %d", s.Down)
+	}
+	return str
+}
@@ -3,6 +3,7 @@ package store
 
 import (
 	"fmt"
+	"io"
 	"strings"
 
 	"odcread/pkg/oberon"
@@ -20,6 +21,16 @@ const (
 	NEWLINK oberon.ShortChar = 0x84 // link to another non-elem store in same file
 )
 
+// AlienComponent is the TurnIntoAlien cause for a store whose embedded
+// store has an unexpected type (e.g. a view whose model is an alien).
+const AlienComponent = 3
+
+// InlineText is implemented by views that stand for a piece of text, such
+// as date stamps. Text extractors render them in place with InlineText.
+type InlineText interface {
+	InlineText() string
+}
+
 // TypePath represents the inheritance path of a type.
 type TypePath []string
 
@@ -43,12 +54,24 @@ type Store interface {
 	// GetTypePath returns the full inheritance path for this type.
 	GetTypePath() TypePath
 
+	// SetTypePath records the type path the store was read with.
+	SetTypePath(path TypePath)
+
+	// GetSpan returns where the store was read from.
+	GetSpan() Span
+
+	// SetSpan records where the store was read from.
+	SetSpan(span Span)
+
+	// GetLinks returns the LINK and NEWLINK references to the store read so far.
+	GetLinks() []Span
+
+	// AddLink records a LINK or NEWLINK reference to the store.
+	AddLink(link Span)
+
 	// Internalize reads the store's contents from the reader.
 	Internalize(reader Reader) error
 
-	// Accept implements the Visitor pattern for traversing the store tree.
-	Accept(visitor Visitor)
-
 	// String returns a debug representation of the store.
 	String() string
 }
@@ -65,24 +88,47 @@ type Reader interface {
 	ReadSChar() (oberon.ShortChar, error)
 	ReadLChar() (oberon.Char, error)
 	ReadSString() (string, error)
+	ReadBool() (oberon.Boolean, error)
+	ReadSet() (oberon.Set, error)
+	ReadLong() (oberon.LongInt, error)
+	ReadSReal() (oberon.ShortReal, error)
+	ReadReal() (oberon.Real, error)
+	// ReadString reads a null-terminated string of 16-bit characters.
+	ReadString() (string, error)
+	// ReadXString reads a null-terminated string of 8-bit characters,
+	// UTF-8 or, in older files, Latin-1.
+	ReadXString() (string, error)
+	// Pos returns the current read position.
+	Pos() int64
+	// ReadSChars fills buf with 8-bit characters.
+	ReadSChars(buf []oberon.ShortChar) error
+	// ReadLChars fills buf with 16-bit characters.
+	ReadLChars(buf []oberon.Char) error
 	IsCancelled() bool
 	TurnIntoAlien(cause int) error
+	// ReserveText checks that a text piece of n bytes may be allocated and read.
+	ReserveText(n int64) error
+	// ReserveData checks that a binary payload of n bytes, such as a
+	// picture, may be allocated and read.
+	ReserveData(n int64) error
+	// Defer skips n bytes and returns a section to read them later when the
+	// reader is lazy; otherwise it returns a nil section and skips nothing.
+	Defer(n int64) (*io.SectionReader, error)
 }
 
-// Visitor interface for the visitor pattern.
-// This is a forward declaration - the actual implementation is in the visitor package.
-type Visitor interface {
-	PartStart()
-	PartEnd()
-	FoldLeft(collapsed bool)
-	FoldRight()
-	// ShouldVisit checks if a store should be visited (returns false if already visited)
-	ShouldVisit(s Store) bool
+// Container is implemented by stores that embed other stores.
+// Traversals use it for store types they have no special knowledge of.
+type Container interface {
+	// Children returns the embedded stores, in document order.
+	Children() []Store
 }
 
 // BaseStore provides common functionality for all Store implementations.
 type BaseStore struct {
-	id oberon.Integer
+	id    oberon.Integer
+	path  TypePath
+	span  Span
+	links []Span
 }
 
 // NewBaseStore creates a new BaseStore with the given ID.
@@ -95,11 +141,44 @@ func (bs *BaseStore) GetID() oberon.Integer {
 	return bs.id
 }
 
-// GetTypePath calculates the full type path for this store.
+// GetTypePath returns the type path the store was read with,
+// or an empty path for stores that were not read from a file.
 func (bs *BaseStore) GetTypePath() TypePath {
-	// This will be implemented by calling calcTypePath recursively
-	// For now, return a simple path with just the type name
-	return TypePath{}
+	if bs.path == nil {
+		return TypePath{}
+	}
+	return bs.path
+}
+
+// SetTypePath records the type path the store was read with.
+func (bs *BaseStore) SetTypePath(path TypePath) {
+	bs.path = path
+}
+
+// GetSpan returns where the store was read from.
+func (bs *BaseStore) GetSpan() Span {
+	return bs.span
+}
+
+// SetSpan records where the store was read from.
+func (bs *BaseStore) SetSpan(span Span) {
+	bs.span = span
+}
+
+// GetLinks returns the LINK and NEWLINK references to the store read so far.
+func (bs *BaseStore) GetLinks() []Span {
+	return bs.links
+}
+
+// AddLink records a LINK or NEWLINK reference to the store.
+// A reference that is read again (e.g. by a lazy reader) is recorded once.
+func (bs *BaseStore) AddLink(link Span) {
+	for _, l := range bs.links {
+		if l.Start == link.Start {
+			return
+		}
+	}
+	bs.links = append(bs.links, link)
 }
 
 // String returns a basic string representation.
@@ -112,8 +191,3 @@ func (bs *BaseStore) Internalize(reader Reader) error {
 	_, err := reader.ReadVersion(0, 0)
 	return err
 }
-
-// Accept is a default implementation that does nothing.
-func (bs *BaseStore) Accept(visitor Visitor) {
-	// Default: no-op
-}
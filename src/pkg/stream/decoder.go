@@ -0,0 +1,340 @@
+// Package stream provides a pull parser that returns a document as a sequence of tokens.
+package stream
+
+import (
+	"fmt"
+	"io"
+	"unicode/utf16"
+
+	"odcread/pkg/alien"
+	"odcread/pkg/encoding"
+	"odcread/pkg/fold"
+	"odcread/pkg/oberon"
+	"odcread/pkg/reader"
+	"odcread/pkg/store"
+	"odcread/pkg/textmodel"
+)
+
+// chunkSize is the maximum number of characters in a Text token.
+const chunkSize = 4096
+
+// Kind identifies the type of a token.
+type Kind int
+
+const (
+	StoreStart Kind = iota // A store begins; its contents follow until the matching StoreEnd
+	StoreEnd               // The innermost open store (or alien) ends
+	Text                   // A chunk of text from a text model
+	ViewEmbed              // A view is embedded in the text; the view's tokens follow
+	FoldBegin              // A fold begins; the tokens of its hidden part follow
+	FoldEnd                // The innermost open fold ends
+	Alien                  // An unregistered store begins; its embedded stores follow until StoreEnd
+	Link                   // A reference (LINK or NEWLINK) to a store that occurs elsewhere
+)
+
+// String returns the name of the token kind.
+func (k Kind) String() string {
+	switch k {
+	case StoreStart:
+		return "StoreStart"
+	case StoreEnd:
+		return "StoreEnd"
+	case Text:
+		return "Text"
+	case ViewEmbed:
+		return "ViewEmbed"
+	case FoldBegin:
+		return "FoldBegin"
+	case FoldEnd:
+		return "FoldEnd"
+	case Alien:
+		return "Alien"
+	case Link:
+		return "Link"
+	default:
+		return fmt.Sprintf("Kind(%d)", int(k))
+	}
+}
+
+// Token is one element of the document. Only the fields that apply to the
+// token's kind are set.
+type Token struct {
+	Kind   Kind
+	Depth  int            // Nesting depth of the store the token belongs to (1 for the root)
+	Offset int64          // Position of the store's marker in the input
+	ID     oberon.Integer // Store ID (of the target for Link)
+	Path   store.TypePath // Type path (StoreStart, StoreEnd, Alien, ViewEmbed, FoldBegin, Link)
+
+	Text       string      // Text: decoded chunk (UTF-8)
+	Attributes store.Store // Text: attributes of the piece the chunk belongs to, or nil
+
+	Width, Height oberon.Integer // ViewEmbed: view size in universal units
+
+	Collapsed bool   // FoldBegin
+	Label     string // FoldBegin
+}
+
+// String returns a one-line description of the token.
+func (t Token) String() string {
+	switch t.Kind {
+	case Text:
+		return fmt.Sprintf("Text %q", t.Text)
+	case ViewEmbed:
+		return fmt.Sprintf("ViewEmbed %s %dx%d", t.Path.String(), t.Width, t.Height)
+	case FoldBegin:
+		return fmt.Sprintf("FoldBegin collapsed=%v label=%q", t.Collapsed, t.Label)
+	case FoldEnd:
+		return "FoldEnd"
+	default:
+		return fmt.Sprintf("%s %s id=%d @%d", t.Kind, t.Path.String(), t.ID, t.Offset)
+	}
+}
+
+// step tells the decoder what to do with the result of frame.next.
+type step int
+
+const (
+	emit    step = iota // Return the token
+	emitPop             // Return the token and leave the frame
+	skip                // No token; ask again
+)
+
+// frame produces the tokens of one open store.
+type frame interface {
+	next(d *Decoder) (Token, step, error)
+}
+
+// Decoder reads a document as a stream of tokens, like encoding/xml.Decoder.Token.
+//
+// The decoder indexes the store headers first and then reads one store at a
+// time; text is read from the input in chunks as it is returned. Memory use
+// is bounded by the index and the open stores, not by the amount of text.
+type Decoder struct {
+	r       *reader.Reader
+	ix      *reader.Index
+	stack   []frame
+	started bool
+
+	short []oberon.ShortChar
+	long  []oberon.Char
+}
+
+// NewDecoder creates a decoder for the store at the reader's current position.
+// The reader must not be used for anything else while the decoder is in use.
+func NewDecoder(r *reader.Reader) *Decoder {
+	return &Decoder{r: r}
+}
+
+// Index returns the store index, or nil before the first call to Next.
+func (d *Decoder) Index() *reader.Index {
+	return d.ix
+}
+
+// Next returns the next token. It returns io.EOF after the root store's last token.
+func (d *Decoder) Next() (Token, error) {
+	if !d.started {
+		d.started = true
+		ix, err := d.r.BuildIndex()
+		if err != nil {
+			return Token{}, err
+		}
+		d.ix = ix
+		tok, st, err := d.enter(ix.Root)
+		if err != nil || st != skip {
+			return tok, err
+		}
+	}
+
+	for len(d.stack) > 0 {
+		top := d.stack[len(d.stack)-1]
+		tok, st, err := top.next(d)
+		if err != nil {
+			return Token{}, err
+		}
+		switch st {
+		case emit:
+			return tok, nil
+		case emitPop:
+			d.stack = d.stack[:len(d.stack)-1]
+			return tok, nil
+		}
+	}
+	return Token{}, io.EOF
+}
+
+// enter reads the store described by the entry and opens a frame for it.
+func (d *Decoder) enter(e *reader.IndexEntry) (Token, step, error) {
+	switch e.Marker {
+	case store.NIL:
+		return Token{}, skip, nil
+	case store.LINK, store.NEWLINK:
+		target, err := d.ix.Target(e)
+		if err != nil {
+			return Token{}, skip, err
+		}
+		return linkToken(target, e.Depth), emit, nil
+	}
+
+	s, err := d.ix.LoadShallow(e)
+	if err != nil {
+		return Token{}, skip, err
+	}
+
+	tok := Token{Kind: StoreStart, Depth: e.Depth, Offset: e.Offset, ID: e.ID, Path: e.Path}
+	switch s := s.(type) {
+	case *textmodel.StdTextModel:
+		d.stack = append(d.stack, &textFrame{entry: e, pieces: s.GetPieces()})
+	case *fold.Fold:
+		d.stack = append(d.stack, &foldFrame{entry: e, hidden: s.GetHidden()})
+		tok.Kind, tok.Collapsed, tok.Label = FoldBegin, s.IsCollapsed(), s.GetLabel()
+	case *alien.Alien:
+		d.stack = append(d.stack, &storeFrame{entry: e})
+		tok.Kind = Alien
+	default:
+		d.stack = append(d.stack, &storeFrame{entry: e})
+	}
+	return tok, emit, nil
+}
+
+// enterStore opens a frame for a store that was returned as a stub by a shallow load.
+func (d *Decoder) enterStore(s store.Store, depth int) (Token, step, error) {
+	stub, ok := s.(*reader.Stub)
+	if !ok {
+		return Token{}, skip, nil
+	}
+	if stub.IsLink() {
+		return linkToken(stub.Entry(), depth), emit, nil
+	}
+	return d.enter(stub.Entry())
+}
+
+// attributes returns the loaded attributes store for a piece.
+func (d *Decoder) attributes(s store.Store) (store.Store, error) {
+	stub, ok := s.(*reader.Stub)
+	if !ok {
+		return s, nil
+	}
+	return d.ix.Load(stub.Entry())
+}
+
+// linkToken returns a Link token referring to the target entry.
+func linkToken(target *reader.IndexEntry, depth int) Token {
+	return Token{Kind: Link, Depth: depth, Offset: target.Offset, ID: target.ID, Path: target.Path}
+}
+
+// endToken returns the StoreEnd token for the entry.
+func endToken(e *reader.IndexEntry) Token {
+	return Token{Kind: StoreEnd, Depth: e.Depth, Offset: e.Offset, ID: e.ID, Path: e.Path}
+}
+
+// storeFrame returns the embedded stores of a generic or alien store, in file order.
+type storeFrame struct {
+	entry *reader.IndexEntry
+	child int
+}
+
+func (f *storeFrame) next(d *Decoder) (Token, step, error) {
+	for f.child < len(f.entry.Children) {
+		c := f.entry.Children[f.child]
+		f.child++
+		if tok, st, err := d.enter(c); err != nil || st != skip {
+			return tok, st, err
+		}
+	}
+	return endToken(f.entry), emitPop, nil
+}
+
+// foldFrame returns the hidden part of a fold.
+type foldFrame struct {
+	entry   *reader.IndexEntry
+	hidden  store.Store
+	visited bool
+}
+
+func (f *foldFrame) next(d *Decoder) (Token, step, error) {
+	if !f.visited {
+		f.visited = true
+		if tok, st, err := d.enterStore(f.hidden, f.entry.Depth+1); err != nil || st != skip {
+			return tok, st, err
+		}
+	}
+	return Token{Kind: FoldEnd, Depth: f.entry.Depth, Offset: f.entry.Offset, ID: f.entry.ID, Path: f.entry.Path}, emitPop, nil
+}
+
+// textFrame returns the text and embedded views of a text model.
+type textFrame struct {
+	entry    *reader.IndexEntry
+	pieces   []textmodel.TextPiece
+	piece    int  // Current piece
+	off      uint // Characters of the current piece already returned
+	embedded bool // ViewEmbed of the current view piece has been returned
+}
+
+func (f *textFrame) next(d *Decoder) (Token, step, error) {
+	for f.piece < len(f.pieces) {
+		var (
+			text string
+			n    int
+			err  error
+		)
+		switch p := f.pieces[f.piece].(type) {
+		case *textmodel.ShortPiece:
+			if d.short == nil {
+				d.short = make([]oberon.ShortChar, chunkSize)
+			}
+			n, err = p.ReadChars(d.short, f.off)
+			if n > 0 {
+				text, err = encoding.ConvertLatin1(d.short[:n])
+			}
+
+		case *textmodel.LongPiece:
+			if d.long == nil {
+				d.long = make([]oberon.Char, chunkSize)
+			}
+			n, err = p.ReadChars(d.long, f.off)
+			if n == len(d.long) && utf16.IsSurrogate(rune(d.long[n-1])) && d.long[n-1] < 0xDC00 {
+				n-- // Keep a high surrogate for the next chunk, which holds its pair
+			}
+			if n > 0 {
+				text, err = encoding.ConvertUCS2(d.long[:n])
+			}
+
+		case *textmodel.ViewPiece:
+			view := p.GetView()
+			if !f.embedded && view != nil {
+				f.embedded = true
+				w, h := p.GetSize()
+				tok := Token{Kind: ViewEmbed, Depth: f.entry.Depth + 1, Width: w, Height: h, Path: view.GetTypePath()}
+				if stub, ok := view.(*reader.Stub); ok {
+					tok.Offset, tok.ID = stub.Entry().Offset, stub.Entry().ID
+				}
+				return tok, emit, nil
+			}
+			f.piece, f.embedded = f.piece+1, false
+			if tok, st, err := d.enterStore(view, f.entry.Depth+1); err != nil || st != skip {
+				return tok, st, err
+			}
+			continue
+		}
+
+		if n == 0 {
+			// Piece exhausted (or truncated in lenient mode)
+			if err != nil && err != io.EOF {
+				return Token{}, skip, fmt.Errorf("failed to read piece %d of store at %d: %w", f.piece, f.entry.Offset, err)
+			}
+			f.piece, f.off = f.piece+1, 0
+			continue
+		}
+		if err != nil && err != io.EOF {
+			return Token{}, skip, err
+		}
+		f.off += uint(n)
+
+		attr, err := d.attributes(f.pieces[f.piece].Attributes())
+		if err != nil {
+			return Token{}, skip, fmt.Errorf("failed to read attributes: %w", err)
+		}
+		return Token{Kind: Text, Depth: f.entry.Depth, Offset: f.entry.Offset, ID: f.entry.ID, Text: text, Attributes: attr}, emit, nil
+	}
+	return endToken(f.entry), emitPop, nil
+}
@@ -0,0 +1,109 @@
+package stream
+
+import (
+	"bytes"
+	"io"
+	"strings"
+	"testing"
+
+	"odcread/internal/testdoc"
+	"odcread/pkg/reader"
+	_ "odcread/pkg/typeregister" // Import for side-effect (type registration)
+)
+
+// foldDoc encodes a text model with the text before, a collapsed fold whose
+// hidden part is a text model, and the text after.
+func foldDoc(before, hidden, after string) []byte {
+	fold := testdoc.Fold(true, "L", testdoc.TextModel(hidden))
+	return testdoc.TextModel(before, testdoc.View{Width: 100, Height: 200, Store: fold}, after)
+}
+
+// collect decodes all tokens of data.
+func collect(t *testing.T, data []byte) []Token {
+	t.Helper()
+	dec := NewDecoder(reader.NewReader(bytes.NewReader(data)))
+	var toks []Token
+	for {
+		tok, err := dec.Next()
+		if err == io.EOF {
+			return toks
+		}
+		if err != nil {
+			t.Fatalf("Next failed after %v: %v", toks, err)
+		}
+		toks = append(toks, tok)
+	}
+}
+
+func TestDecoder_Fold(t *testing.T) {
+	toks := collect(t, foldDoc("Before ", "Hidden", " after"))
+
+	want := []string{
+		"StoreStart", "Text Before ", "ViewEmbed", "FoldBegin",
+		"StoreStart", "Text Hidden", "StoreEnd",
+		"FoldEnd", "Text  after", "StoreEnd",
+	}
+	if len(toks) != len(want) {
+		t.Fatalf("Expected %d tokens, got %d: %v", len(want), len(toks), toks)
+	}
+	for i, tok := range toks {
+		got := tok.Kind.String()
+		if tok.Kind == Text {
+			got += " " + tok.Text
+		}
+		if got != want[i] {
+			t.Errorf("Token %d: expected %q, got %q", i, want[i], got)
+		}
+	}
+
+	if toks[2].Width != 100 || toks[2].Height != 200 || toks[2].Path[0] != "StdFolds.Fold^" {
+		t.Errorf("Unexpected ViewEmbed: %s", toks[2])
+	}
+	if !toks[3].Collapsed || toks[3].Label != "L" {
+		t.Errorf("Unexpected FoldBegin: %s", toks[3])
+	}
+	if toks[4].Depth != 3 || toks[0].Depth != 1 {
+		t.Errorf("Unexpected depths: %d, %d", toks[0].Depth, toks[4].Depth)
+	}
+}
+
+func TestDecoder_Chunks(t *testing.T) {
+	text := strings.Repeat("x", 2*chunkSize+10)
+	toks := collect(t, testdoc.TextModel(text))
+
+	var got strings.Builder
+	chunks := 0
+	for _, tok := range toks {
+		if tok.Kind == Text {
+			got.WriteString(tok.Text)
+			chunks++
+		}
+	}
+	if chunks != 3 {
+		t.Errorf("Expected 3 text chunks, got %d", chunks)
+	}
+	if got.String() != text {
+		t.Errorf("Reassembled text differs (len %d, want %d)", got.Len(), len(text))
+	}
+}
+
+func TestDecoder_SurrogatePairAcrossChunks(t *testing.T) {
+	// A pair at characters chunkSize-1 and chunkSize of a long piece
+	text := strings.Repeat("x", chunkSize-1) + "\U0001F600" + "y"
+	toks := collect(t, testdoc.TextModel(testdoc.Long(text)))
+
+	var got strings.Builder
+	chunks := 0
+	for _, tok := range toks {
+		if tok.Kind == Text {
+			got.WriteString(tok.Text)
+			chunks++
+		}
+	}
+	if chunks != 2 {
+		t.Errorf("Expected 2 text chunks, got %d", chunks)
+	}
+	if got.String() != text {
+		t.Errorf("Reassembled text differs: ends in %q", got.String()[got.Len()-8:])
+	}
+}
@@ -0,0 +1,116 @@
+// Package tabview provides tab views (StdTabViews), which show one of
+// several labelled views at a time.
+package tabview
+
+import (
+	"fmt"
+
+	"odcread/pkg/fold"
+	"odcread/pkg/oberon"
+	"odcread/pkg/store"
+)
+
+const TypeNameView = "StdTabViews.View^"
+
+// maxTabs bounds the number of tabs read from a file.
+const maxTabs = 1024
+
+// Tab is a labelled view of a tab view.
+type Tab struct {
+	Label string
+	View  store.Store
+}
+
+// View is a tab view (StdTabViews.View).
+type View struct {
+	fold.View
+	tabs     []Tab
+	index    oberon.Integer // Selected tab
+	notifier string
+}
+
+// NewView creates a new View instance.
+func NewView(id oberon.Integer) *View {
+	return &View{
+		View: *fold.NewView(id),
+	}
+}
+
+// GetTypeName returns the type name for View.
+func (v *View) GetTypeName() string {
+	return TypeNameView
+}
+
+// Internalize reads View data from the reader.
+// Format (StdTabViews.View.Internalize):
+//
+//	version (0..1)
+//	number of tabs (int), then for each tab: label (string), view (store)
+//	selected tab (int)
+//	notifier (string), if version 1
+func (v *View) Internalize(reader store.Reader) error {
+	if err := v.View.Internalize(reader); err != nil {
+		return err
+	}
+	version, err := reader.ReadVersion(0, 1)
+	if err != nil {
+		return err
+	}
+
+	n, err := reader.ReadInt()
+	if err != nil {
+		return fmt.Errorf("failed to read number of tabs: %w", err)
+	}
+	if n < 0 || n > maxTabs {
+		return fmt.Errorf("invalid number of tabs %d", n)
+	}
+	v.tabs = make([]Tab, n)
+	for i := range v.tabs {
+		if v.tabs[i].Label, err = reader.ReadString(); err != nil {
+			return fmt.Errorf("failed to read label of tab %d: %w", i+1, err)
+		}
+		if v.tabs[i].View, err = reader.ReadStore(); err != nil {
+			return fmt.Errorf("failed to read view of tab %d: %w", i+1, err)
+		}
+	}
+	if v.index, err = reader.ReadInt(); err != nil {
+		return fmt.Errorf("failed to read selected tab: %w", err)
+	}
+	if version > 0 {
+		if v.notifier, err = reader.ReadString(); err != nil {
+			return fmt.Errorf("failed to read tab notifier: %w", err)
+		}
+	}
+	return nil
+}
+
+// String returns a string representation of the View.
+func (v *View) String() string {
+	return fmt.Sprintf("TabView{id: %d, tabs: %d}", v.GetID(), len(v.tabs))
+}
+
+// GetTabs returns the tabs, in display order.
+func (v *View) GetTabs() []Tab {
+	return v.tabs
+}
+
+// GetIndex returns the index of the selected tab.
+func (v *View) GetIndex() oberon.Integer {
+	return v.index
+}
+
+// GetNotifier returns the procedure called when another tab is selected, or "".
+func (v *View) GetNotifier() string {
+	return v.notifier
+}
+
+// Children returns the views of the tabs.
+func (v *View) Children() []store.Store {
+	var list []store.Store
+	for _, t := range v.tabs {
+		if t.View != nil {
+			list = append(list, t.View)
+		}
+	}
+	return list
+}
@@ -0,0 +1,67 @@
+package tabview_test
+
+import (
+	"strings"
+	"testing"
+
+	"odcread/internal/odc"
+	"odcread/internal/testdoc"
+	"odcread/internal/testdoc/load"
+	"odcread/pkg/extract"
+	"odcread/pkg/tabview"
+	_ "odcread/pkg/typeregister" // Import for side-effect (type registration)
+)
+
+// TestCycle checks that a tab whose view links back to its own tab view is
+// shown once instead of recursing forever.
+func TestCycle(t *testing.T) {
+	loop := []byte{0x84, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0} // NEWLINK to store 0, the tab view itself
+	doc := testdoc.TabView(testdoc.Tab{Label: "loop", View: loop})
+	s := load.Store(t, doc)
+	tv, ok := s.(*tabview.View)
+	if !ok {
+		t.Fatalf("Expected a tab view, got %v", s)
+	}
+	if tabs := tv.GetTabs(); len(tabs) != 1 || tabs[0].View != tv {
+		t.Fatalf("Expected one tab showing the tab view itself, got %v", tabs)
+	}
+
+	if got := extract.Extract(s).Text; got != "loop\n" {
+		t.Errorf("Expected %q, got %q", "loop\n", got)
+	}
+	var sb strings.Builder
+	odc.NewMyVisitor(&sb).Visit(s)
+	if sb.String() != "[loop]\n" {
+		t.Errorf("Expected %q, got %q", "[loop]\n", sb.String())
+	}
+}
+
+// TestSharedView checks that a view shared by two tabs is written once.
+func TestSharedView(t *testing.T) {
+	shared := []byte{0x84, 1, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0} // NEWLINK to store 1, the first tab's view
+	doc := testdoc.TabView(
+		testdoc.Tab{Label: "First", View: testdoc.StdView(testdoc.TextModel("Shared"), 0, false)},
+		testdoc.Tab{Label: "Second", View: shared},
+	)
+	s := load.Store(t, doc)
+	tabs := s.(*tabview.View).GetTabs()
+	if len(tabs) != 2 || tabs[0].View == nil || tabs[1].View != tabs[0].View {
+		t.Fatalf("Expected two tabs showing the same view, got %v", tabs)
+	}
+
+	var sb strings.Builder
+	odc.NewMyVisitor(&sb).Visit(s)
+	if got, want := sb.String(), "[First]\nShared\n[Second]\n"; got != want {
+		t.Errorf("Expected %q, got %q", want, got)
+	}
+}
+
+// TestView_Corpus checks the tab views of the documents written by BlackBox.
+func TestView_Corpus(t *testing.T) {
+	for _, s := range load.Corpus(t, tabview.TypeNameView) {
+		v := s.(*tabview.View)
+		if n := len(v.GetTabs()); n > 0 && (v.GetIndex() < 0 || int(v.GetIndex()) >= n) {
+			t.Errorf("Expected the selected tab in [0, %d), got %d", n, v.GetIndex())
+		}
+	}
+}
@@ -2,7 +2,9 @@
 package textmodel
 
 import (
+	"encoding/binary"
 	"fmt"
+	"io"
 
 	"odcread/pkg/oberon"
 	"odcread/pkg/store"
@@ -47,16 +49,34 @@ type TextPiece interface {
 	// String returns a debug representation.
 	String() string
 
-	// Accept implements the visitor pattern.
-	Accept(visitor store.Visitor)
-
 	// Size returns the size in bytes (excluding null terminator).
 	Size() uint
+
+	// Attributes returns the attributes store of the piece, or nil.
+	Attributes() store.Store
+
+	// GetSpan returns where the piece content was read from.
+	GetSpan() store.Span
+
+	setAttributes(attr store.Store)
 }
 
 // basePiece provides common functionality for text pieces.
 type basePiece struct {
 	length uint
+	attr   store.Store
+	span   store.Span
+}
+
+// GetSpan returns where the piece content was read from.
+func (bp *basePiece) GetSpan() store.Span {
+	return bp.span
+}
+
+// setSpan records that the piece content occupies n bytes at the reader's position.
+func (bp *basePiece) setSpan(reader store.Reader, n int64) {
+	start := reader.Pos()
+	bp.span = store.Span{Start: start, Content: start, End: start + n}
 }
 
 // Size returns the piece size in bytes.
@@ -64,37 +84,85 @@ func (bp *basePiece) Size() uint {
 	return bp.length
 }
 
+// Attributes returns the attributes store of the piece, or nil.
+func (bp *basePiece) Attributes() store.Store {
+	return bp.attr
+}
+
+func (bp *basePiece) setAttributes(attr store.Store) {
+	bp.attr = attr
+}
+
 // ShortPiece represents a text piece with 8-bit Latin-1 characters.
 type ShortPiece struct {
 	basePiece
-	buffer []oberon.ShortChar
+	buffer  []oberon.ShortChar
+	section *io.SectionReader // Unread content (lazy mode)
 }
 
 // NewShortPiece creates a new ShortPiece with the given length.
 func NewShortPiece(length uint) *ShortPiece {
 	return &ShortPiece{
 		basePiece: basePiece{length: length},
-		buffer:    make([]oberon.ShortChar, length+1), // +1 for null terminator
 	}
 }
 
 // Read reads the short piece content from the reader.
+// A lazy reader only records where the content is; it is read by Load.
 func (sp *ShortPiece) Read(reader store.Reader) error {
-	// Read exactly 'length' characters (not length+1)
-	for i := 0; i < int(sp.length); i++ {
-		ch, err := reader.ReadSChar()
-		if err != nil {
-			return fmt.Errorf("failed to read short char at position %d: %w", i, err)
-		}
-		sp.buffer[i] = ch
-	}
-	// Null-terminate
-	sp.buffer[sp.length] = 0
+	sp.setSpan(reader, int64(sp.length))
+	section, err := reader.Defer(int64(sp.length))
+	if err != nil {
+		return err
+	}
+	if section != nil {
+		sp.section = section
+		return nil
+	}
+
+	// Read exactly 'length' characters, plus a null terminator
+	sp.buffer = make([]oberon.ShortChar, sp.length+1)
+	return reader.ReadSChars(sp.buffer[:sp.length])
+}
+
+// Load reads deferred content. It does nothing if the content is already in memory.
+func (sp *ShortPiece) Load() error {
+	if sp.section == nil {
+		return nil
+	}
+	buf := make([]oberon.ShortChar, sp.length+1)
+	if _, err := sp.section.ReadAt(buf[:sp.length], 0); err != nil && err != io.EOF {
+		return fmt.Errorf("failed to load short piece: %w", err)
+	}
+	sp.buffer, sp.section = buf, nil
 	return nil
 }
 
-// GetBuffer returns the raw buffer contents.
+// ReadChars copies up to len(buf) characters starting at character off into buf,
+// reading deferred content directly from the input, and returns the number copied.
+func (sp *ShortPiece) ReadChars(buf []oberon.ShortChar, off uint) (int, error) {
+	if off >= sp.length {
+		return 0, io.EOF
+	}
+	if rest := sp.length - off; uint(len(buf)) > rest {
+		buf = buf[:rest]
+	}
+	if sp.section == nil {
+		return copy(buf, sp.buffer[off:sp.length]), nil
+	}
+	n, err := sp.section.ReadAt(buf, int64(off))
+	if err == io.EOF && n == len(buf) {
+		err = nil
+	}
+	return n, err
+}
+
+// GetBuffer returns the raw buffer contents, loading deferred content first.
+// Deferred content that cannot be loaded reads as empty.
 func (sp *ShortPiece) GetBuffer() []oberon.ShortChar {
+	if sp.Load() != nil {
+		return []oberon.ShortChar{0}
+	}
 	return sp.buffer
 }
 
@@ -103,46 +171,85 @@ func (sp *ShortPiece) String() string {
 	return fmt.Sprintf("ShortPiece{len: %d}", sp.length)
 }
 
-// Accept implements the visitor pattern.
-func (sp *ShortPiece) Accept(visitor store.Visitor) {
-	// Type-assert to the full visitor interface if available
-	if v, ok := visitor.(interface{ TextShortPiece(interface{}) }); ok {
-		v.TextShortPiece(sp)
-	}
-}
-
 // LongPiece represents a text piece with 16-bit Unicode characters.
 type LongPiece struct {
 	basePiece
-	buffer []oberon.Char
+	buffer  []oberon.Char
+	section *io.SectionReader // Unread content (lazy mode)
 }
 
 // NewLongPiece creates a new LongPiece with the given length.
 func NewLongPiece(length uint) *LongPiece {
 	return &LongPiece{
 		basePiece: basePiece{length: length},
-		buffer:    make([]oberon.Char, length+1), // +1 for null terminator
 	}
 }
 
 // Read reads the long piece content from the reader.
+// A lazy reader only records where the content is; it is read by Load.
 func (lp *LongPiece) Read(reader store.Reader) error {
-	// Read exactly 'length' characters (length is in chars, not bytes)
-	// Note: length here is already adjusted (d_len/2 in C++)
-	for i := 0; i < int(lp.length); i++ {
-		ch, err := reader.ReadLChar()
-		if err != nil {
-			return fmt.Errorf("failed to read long char at position %d: %w", i, err)
-		}
-		lp.buffer[i] = ch
-	}
-	// Null-terminate
-	lp.buffer[lp.length] = 0
+	// length is in chars, not bytes (d_len/2 in C++)
+	lp.setSpan(reader, 2*int64(lp.length))
+	section, err := reader.Defer(2 * int64(lp.length))
+	if err != nil {
+		return err
+	}
+	if section != nil {
+		lp.section = section
+		return nil
+	}
+
+	// Read exactly 'length' characters, plus a null terminator
+	lp.buffer = make([]oberon.Char, lp.length+1)
+	return reader.ReadLChars(lp.buffer[:lp.length])
+}
+
+// Load reads deferred content. It does nothing if the content is already in memory.
+func (lp *LongPiece) Load() error {
+	if lp.section == nil {
+		return nil
+	}
+	raw := make([]byte, 2*lp.length)
+	if _, err := lp.section.ReadAt(raw, 0); err != nil && err != io.EOF {
+		return fmt.Errorf("failed to load long piece: %w", err)
+	}
+	buf := make([]oberon.Char, lp.length+1)
+	for i := range buf[:lp.length] {
+		buf[i] = binary.LittleEndian.Uint16(raw[2*i:])
+	}
+	lp.buffer, lp.section = buf, nil
 	return nil
 }
 
-// GetBuffer returns the raw buffer contents.
+// ReadChars copies up to len(buf) characters starting at character off into buf,
+// reading deferred content directly from the input, and returns the number copied.
+func (lp *LongPiece) ReadChars(buf []oberon.Char, off uint) (int, error) {
+	if off >= lp.length {
+		return 0, io.EOF
+	}
+	if rest := lp.length - off; uint(len(buf)) > rest {
+		buf = buf[:rest]
+	}
+	if lp.section == nil {
+		return copy(buf, lp.buffer[off:lp.length]), nil
+	}
+	raw := make([]byte, 2*len(buf))
+	n, err := lp.section.ReadAt(raw, 2*int64(off))
+	for i := 0; i < n/2; i++ {
+		buf[i] = binary.LittleEndian.Uint16(raw[2*i:])
+	}
+	if err == io.EOF && n == len(raw) {
+		err = nil
+	}
+	return n / 2, err
+}
+
+// GetBuffer returns the raw buffer contents, loading deferred content first.
+// Deferred content that cannot be loaded reads as empty.
 func (lp *LongPiece) GetBuffer() []oberon.Char {
+	if lp.Load() != nil {
+		return []oberon.Char{0}
+	}
 	return lp.buffer
 }
 
@@ -151,31 +258,27 @@ func (lp *LongPiece) String() string {
 	return fmt.Sprintf("LongPiece{len: %d}", lp.length)
 }
 
-// Accept implements the visitor pattern.
-func (lp *LongPiece) Accept(visitor store.Visitor) {
-	// Type-assert to the full visitor interface if available
-	if v, ok := visitor.(interface{ TextLongPiece(interface{}) }); ok {
-		v.TextLongPiece(lp)
-	}
-}
-
 // ViewPiece represents a text piece that embeds a View.
 type ViewPiece struct {
 	basePiece
-	view store.Store
+	view          store.Store
+	width, height oberon.Integer
 }
 
-// NewViewPiece creates a new ViewPiece with the given view.
-func NewViewPiece(view store.Store) *ViewPiece {
+// NewViewPiece creates a new ViewPiece with the given view and size (in universal units).
+func NewViewPiece(view store.Store, width, height oberon.Integer) *ViewPiece {
 	return &ViewPiece{
 		basePiece: basePiece{length: 1}, // View pieces have length 1
 		view:      view,
+		width:     width,
+		height:    height,
 	}
 }
 
 // Read reads the view piece (reads one extra byte as per C++ implementation).
 func (vp *ViewPiece) Read(reader store.Reader) error {
 	// ViewPiece requires reading one extra byte
+	vp.setSpan(reader, 1)
 	_, err := reader.ReadByte()
 	return err
 }
@@ -188,14 +291,12 @@ func (vp *ViewPiece) String() string {
 	return "ViewPiece{view: nil}"
 }
 
-// Accept implements the visitor pattern.
-func (vp *ViewPiece) Accept(visitor store.Visitor) {
-	if vp.view != nil {
-		vp.view.Accept(visitor)
-	}
-}
-
 // GetView returns the embedded view.
 func (vp *ViewPiece) GetView() store.Store {
 	return vp.view
 }
+
+// GetSize returns the width and height of the embedded view (in universal units).
+func (vp *ViewPiece) GetSize() (width, height oberon.Integer) {
+	return vp.width, vp.height
+}
@@ -77,8 +77,9 @@ func (stm *StdTextModel) Internalize(reader store.Reader) error {
 				return fmt.Errorf("failed to read attribute store: %w", err)
 			}
 			dict = append(dict, attr)
+		} else if ano < 0 || int(ano) > len(dict) {
+			return fmt.Errorf("invalid attribute index %d (dictionary has %d)", ano, len(dict))
 		}
-		// Note: We don't use the attribute for now, but it's stored for future use
 
 		// Read piece length
 		pieceLen, err := reader.ReadInt()
@@ -89,19 +90,25 @@ func (stm *StdTextModel) Internalize(reader store.Reader) error {
 		var piece TextPiece
 		if pieceLen > 0 {
 			// ShortPiece (8-bit characters)
+			if err := reader.ReserveText(int64(pieceLen)); err != nil {
+				return err
+			}
 			piece = NewShortPiece(uint(pieceLen))
 		} else if pieceLen < 0 {
 			// LongPiece (16-bit characters)
 			// pieceLen is negative and in bytes, so divide by 2 for character count
-			piece = NewLongPiece(uint(-pieceLen / 2))
+			if err := reader.ReserveText(-int64(pieceLen)); err != nil {
+				return err
+			}
+			piece = NewLongPiece(uint(-int64(pieceLen) / 2))
 		} else {
 			// ViewPiece (embedded view, pieceLen == 0)
-			// Read view width and height (ignored)
-			_, err := reader.ReadInt()
+			// Read view width and height
+			width, err := reader.ReadInt()
 			if err != nil {
 				return fmt.Errorf("failed to read view width: %w", err)
 			}
-			_, err = reader.ReadInt()
+			height, err := reader.ReadInt()
 			if err != nil {
 				return fmt.Errorf("failed to read view height: %w", err)
 			}
@@ -111,9 +118,10 @@ func (stm *StdTextModel) Internalize(reader store.Reader) error {
 			if err != nil {
 				return fmt.Errorf("failed to read embedded view: %w", err)
 			}
-			piece = NewViewPiece(view)
+			piece = NewViewPiece(view, width, height)
 		}
 
+		piece.setAttributes(dict[ano])
 		stm.pieces = append(stm.pieces, piece)
 
 		// Read next ano
@@ -144,20 +152,6 @@ func (stm *StdTextModel) String() string {
 	return fmt.Sprintf("StdTextModel{id: %d, pieces: %d}", stm.GetID(), len(stm.pieces))
 }
 
-// Accept implements the visitor pattern for StdTextModel.
-func (stm *StdTextModel) Accept(visitor store.Visitor) {
-	// Check if this text model has already been visited (prevents cycles from LINK/NEWLINK)
-	if !visitor.ShouldVisit(stm) {
-		return
-	}
-
-	visitor.PartStart()
-	for _, piece := range stm.pieces {
-		piece.Accept(visitor)
-	}
-	visitor.PartEnd()
-}
-
 // GetPieces returns the text pieces (for testing/debugging).
 func (stm *StdTextModel) GetPieces() []TextPiece {
 	return stm.pieces
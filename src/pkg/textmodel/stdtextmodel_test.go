@@ -5,6 +5,7 @@ import (
 	"encoding/binary"
 	"fmt"
 	"io"
+	"math"
 	"testing"
 
 	"odcread/pkg/oberon"
@@ -22,6 +23,10 @@ func NewMockReader(data []byte) *MockReader {
 	return &MockReader{data: data, stores: make([]store.Store, 0)}
 }
 
+func (m *MockReader) Pos() int64 {
+	return int64(m.pos)
+}
+
 func (m *MockReader) ReadByte() (byte, error) {
 	if m.pos >= len(m.data) {
 		return 0, io.EOF
@@ -75,10 +80,91 @@ func (m *MockReader) ReadLChar() (oberon.Char, error) {
 	return oberon.Char(val), nil
 }
 
+func (m *MockReader) ReadSChars(buf []oberon.ShortChar) error {
+	for i := range buf {
+		ch, err := m.ReadSChar()
+		if err != nil {
+			return err
+		}
+		buf[i] = ch
+	}
+	return nil
+}
+
+func (m *MockReader) ReadLChars(buf []oberon.Char) error {
+	for i := range buf {
+		ch, err := m.ReadLChar()
+		if err != nil {
+			return err
+		}
+		buf[i] = ch
+	}
+	return nil
+}
+
+func (m *MockReader) Defer(n int64) (*io.SectionReader, error) {
+	return nil, nil
+}
+
 func (m *MockReader) ReadSString() (string, error) {
 	return "", fmt.Errorf("not implemented")
 }
 
+// next returns the next n bytes of data.
+func (m *MockReader) next(n int) ([]byte, error) {
+	if m.pos+n > len(m.data) {
+		return nil, io.EOF
+	}
+	b := m.data[m.pos : m.pos+n]
+	m.pos += n
+	return b, nil
+}
+
+func (m *MockReader) ReadBool() (oberon.Boolean, error) {
+	b, err := m.ReadByte()
+	return b != 0, err
+}
+
+func (m *MockReader) ReadSet() (oberon.Set, error) {
+	b, err := m.next(4)
+	if err != nil {
+		return 0, err
+	}
+	return binary.LittleEndian.Uint32(b), nil
+}
+
+func (m *MockReader) ReadLong() (oberon.LongInt, error) {
+	b, err := m.next(8)
+	if err != nil {
+		return 0, err
+	}
+	return oberon.LongInt(binary.LittleEndian.Uint64(b)), nil
+}
+
+func (m *MockReader) ReadSReal() (oberon.ShortReal, error) {
+	b, err := m.next(4)
+	if err != nil {
+		return 0, err
+	}
+	return math.Float32frombits(binary.LittleEndian.Uint32(b)), nil
+}
+
+func (m *MockReader) ReadReal() (oberon.Real, error) {
+	b, err := m.next(8)
+	if err != nil {
+		return 0, err
+	}
+	return math.Float64frombits(binary.LittleEndian.Uint64(b)), nil
+}
+
+func (m *MockReader) ReadString() (string, error) {
+	return "", fmt.Errorf("not implemented")
+}
+
+func (m *MockReader) ReadXString() (string, error) {
+	return "", fmt.Errorf("not implemented")
+}
+
 func (m *MockReader) ReadVersion(min, max oberon.Integer) (oberon.Integer, error) {
 	b, err := m.ReadSignedByte()
 	if err != nil {
@@ -113,6 +199,17 @@ func (m *MockReader) TurnIntoAlien(cause int) error {
 	return fmt.Errorf("turned into alien")
 }
 
+func (m *MockReader) ReserveText(n int64) error {
+	if n > int64(len(m.data)-m.pos) {
+		return io.ErrUnexpectedEOF
+	}
+	return nil
+}
+
+func (m *MockReader) ReserveData(n int64) error {
+	return m.ReserveText(n)
+}
+
 func TestStdTextModel_Internalize_Empty(t *testing.T) {
 	// Hierarchy: StdTextModel -> TextModel -> ContainerModel -> Model -> Elem -> BaseStore
 	// Each level reads a version byte. Total 6 levels.
@@ -0,0 +1,128 @@
+// Package textview provides the standard text view, which shows a text model.
+package textview
+
+import (
+	"fmt"
+
+	"odcread/pkg/container"
+	"odcread/pkg/oberon"
+	"odcread/pkg/store"
+	"odcread/pkg/textmodel"
+	"odcread/pkg/visitor"
+)
+
+const TypeNameStdView = "TextViews.StdView^"
+
+// StdView is the standard view of a text model (TextViews.StdView).
+type StdView struct {
+	container.View
+	ruler     store.Store // Default ruler
+	attr      store.Store // Default attributes
+	org       oberon.Integer
+	hideMarks bool
+}
+
+// NewStdView creates a new StdView instance.
+func NewStdView(id oberon.Integer) *StdView {
+	return &StdView{
+		View: *container.NewView(id),
+	}
+}
+
+// GetTypeName returns the type name for StdView.
+func (v *StdView) GetTypeName() string {
+	return TypeNameStdView
+}
+
+// Internalize reads StdView data from the reader.
+// Format (TextViews.StdView.Internalize2):
+//
+//	version (0)
+//	default ruler (store)
+//	default attributes (store)
+//	scroll origin (int)
+//	hideMarks (bool)
+func (v *StdView) Internalize(reader store.Reader) error {
+	if err := v.View.Internalize(reader); err != nil {
+		return err
+	}
+	if _, err := reader.ReadVersion(0, 0); err != nil {
+		return err
+	}
+
+	var err error
+	if v.ruler, err = reader.ReadStore(); err != nil {
+		return fmt.Errorf("failed to read default ruler: %w", err)
+	}
+	if v.attr, err = reader.ReadStore(); err != nil {
+		return fmt.Errorf("failed to read default attributes: %w", err)
+	}
+	if v.org, err = reader.ReadInt(); err != nil {
+		return fmt.Errorf("failed to read scroll origin: %w", err)
+	}
+	if v.hideMarks, err = reader.ReadBool(); err != nil {
+		return fmt.Errorf("failed to read hideMarks: %w", err)
+	}
+	return nil
+}
+
+// String returns a string representation of the StdView.
+func (v *StdView) String() string {
+	return fmt.Sprintf("StdView{id: %d, org: %d, hideMarks: %v}", v.GetID(), v.org, v.hideMarks)
+}
+
+// GetText returns the view's model if it is a standard text model, or nil.
+func (v *StdView) GetText() *textmodel.StdTextModel {
+	tm, _ := v.GetModel().(*textmodel.StdTextModel)
+	return tm
+}
+
+// GetDefaultRuler returns the ruler used where the text has none.
+func (v *StdView) GetDefaultRuler() store.Store {
+	return v.ruler
+}
+
+// GetDefaultAttributes returns the attributes used for new text.
+func (v *StdView) GetDefaultAttributes() store.Store {
+	return v.attr
+}
+
+// GetOrigin returns the text position shown at the top of the view.
+func (v *StdView) GetOrigin() oberon.Integer {
+	return v.org
+}
+
+// HideMarks returns whether paragraph and tab marks are hidden.
+func (v *StdView) HideMarks() bool {
+	return v.hideMarks
+}
+
+// Children returns the model, the controller, the default ruler and the
+// default attributes.
+func (v *StdView) Children() []store.Store {
+	children := v.View.Children()
+	for _, s := range []store.Store{v.ruler, v.attr} {
+		if s != nil {
+			children = append(children, s)
+		}
+	}
+	return children
+}
+
+// MainText returns root if it is a text model, or else the model of the
+// first text view in root in document order. It returns nil if there is
+// no such model.
+func MainText(root store.Store) *textmodel.StdTextModel {
+	if tm, ok := root.(*textmodel.StdTextModel); ok {
+		return tm
+	}
+	var text *textmodel.StdTextModel
+	visitor.Walk(root, func(a visitor.Ancestry) visitor.Action {
+		if v, ok := a.Store().(*StdView); ok && v.GetText() != nil {
+			text = v.GetText()
+			return visitor.Stop
+		}
+		return visitor.Continue
+	})
+	return text
+}
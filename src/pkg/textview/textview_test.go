@@ -0,0 +1,52 @@
+package textview_test
+
+import (
+	"testing"
+
+	"odcread/internal/testdoc"
+	"odcread/internal/testdoc/load"
+	"odcread/pkg/alien"
+	"odcread/pkg/textview"
+	_ "odcread/pkg/typeregister" // Import for side-effect (type registration)
+)
+
+func TestStdView(t *testing.T) {
+	doc := testdoc.StdView(testdoc.TextModel("Hello"), 3, true)
+	s := load.Store(t, doc)
+	v, ok := s.(*textview.StdView)
+	if !ok {
+		t.Fatalf("Expected *StdView, got %s", s)
+	}
+	if v.GetOrigin() != 3 || !v.HideMarks() || v.GetController() != nil {
+		t.Errorf("Unexpected view state: %s", v)
+	}
+	if v.GetDefaultRuler() == nil || v.GetDefaultAttributes() == nil {
+		t.Error("Expected default ruler and attributes")
+	}
+	if n := len(v.Children()); n != 3 {
+		t.Errorf("Expected 3 children, got %d", n)
+	}
+	if textview.MainText(v) == nil || textview.MainText(v) != v.GetText() {
+		t.Error("Expected MainText to return the view's model")
+	}
+}
+
+func TestStdView_AlienModel(t *testing.T) {
+	doc := testdoc.StdView(testdoc.Opaque("Foo.ModelDesc"), 0, false)
+	s := load.Store(t, doc)
+	if _, ok := s.(*alien.Alien); !ok {
+		t.Fatalf("Expected a view with an alien model to be an alien, got %s", s)
+	}
+	if textview.MainText(s) != nil {
+		t.Error("Expected no main text")
+	}
+}
+
+// TestStdView_Corpus checks the text views of the documents written by BlackBox.
+func TestStdView_Corpus(t *testing.T) {
+	for _, s := range load.Corpus(t, textview.TypeNameStdView) {
+		if v := s.(*textview.StdView); v.GetText() == nil {
+			t.Errorf("Expected the view's text, got %s", v)
+		}
+	}
+}
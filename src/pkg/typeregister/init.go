@@ -2,9 +2,24 @@
 package typeregister
 
 import (
+	"odcread/pkg/clock"
+	"odcread/pkg/commander"
+	"odcread/pkg/container"
+	"odcread/pkg/control"
+	"odcread/pkg/document"
+	"odcread/pkg/doodle"
 	"odcread/pkg/fold"
+	"odcread/pkg/form"
+	"odcread/pkg/header"
+	"odcread/pkg/link"
+	"odcread/pkg/marker"
+	"odcread/pkg/picture"
+	"odcread/pkg/ruler"
+	"odcread/pkg/stamp"
 	"odcread/pkg/store"
+	"odcread/pkg/tabview"
 	"odcread/pkg/textmodel"
+	"odcread/pkg/textview"
 )
 
 // init registers all known types with the TypeRegister.
@@ -43,4 +58,119 @@ func init() {
 	Register(fold.TypeNameFold, func(id int32) store.Store {
 		return fold.NewFold(id)
 	})
+
+	Register(container.TypeNameView, func(id int32) store.Store {
+		return container.NewView(id)
+	})
+
+	// Register TextView hierarchy
+	Register(textview.TypeNameStdView, func(id int32) store.Store {
+		return textview.NewStdView(id)
+	})
+
+	// Register Document hierarchy
+	Register(document.TypeNameModel, func(id int32) store.Store {
+		return document.NewModel(id)
+	})
+
+	Register(document.TypeNameStdDocument, func(id int32) store.Store {
+		return document.NewStdDocument(id)
+	})
+
+	// Register TextRuler hierarchy
+	Register(ruler.TypeNameAttributes, func(id int32) store.Store {
+		return ruler.NewAttributes(id)
+	})
+
+	Register(ruler.TypeNameStdStyle, func(id int32) store.Store {
+		return ruler.NewStdStyle(id)
+	})
+
+	Register(ruler.TypeNameStdRuler, func(id int32) store.Store {
+		return ruler.NewStdRuler(id)
+	})
+
+	// Register StdLinks hierarchy
+	Register(link.TypeNameLink, func(id int32) store.Store {
+		return link.NewLink(id)
+	})
+
+	Register(link.TypeNameTarget, func(id int32) store.Store {
+		return link.NewTarget(id)
+	})
+
+	// Register StdStamps, StdClocks and StdHeaders views
+	Register(stamp.TypeNameStamp, func(id int32) store.Store {
+		return stamp.NewStamp(id)
+	})
+
+	Register(clock.TypeNameClock, func(id int32) store.Store {
+		return clock.NewClock(id)
+	})
+
+	Register(header.TypeNameHeader, func(id int32) store.Store {
+		return header.NewHeader(id)
+	})
+
+	// Register FormModels, FormViews and Controls hierarchy
+	Register(form.TypeNameStdModel, func(id int32) store.Store {
+		return form.NewStdModel(id)
+	})
+
+	Register(form.TypeNameStdView, func(id int32) store.Store {
+		return form.NewStdView(id)
+	})
+
+	for _, name := range control.TypeNames {
+		name := name
+		Register(name, func(id int32) store.Store {
+			return control.New(id, name)
+		})
+	}
+
+	// Register DevCommanders and DevMarkers views
+	Register(commander.TypeNameStdView, func(id int32) store.Store {
+		return commander.NewStdView(id)
+	})
+
+	Register(commander.TypeNameStdEndView, func(id int32) store.Store {
+		return commander.NewStdEndView(id)
+	})
+
+	Register(marker.TypeNameStdView, func(id int32) store.Store {
+		return marker.NewStdView(id)
+	})
+
+	// Register HostPictures views
+	Register(picture.TypeNameStdView, func(id int32) store.Store {
+		return picture.NewStdView(id)
+	})
+
+	// Register StdTabViews views
+	Register(tabview.TypeNameView, func(id int32) store.Store {
+		return tabview.NewView(id)
+	})
+}
+
+// RegisterDoodles registers the WinBUGS graphical model types
+// (DoodleModels, DoodleNodes, DoodlePlates and DoodleViews). They are not
+// registered by init, as their layout has not been checked against files
+// written by WinBUGS (see package doodle); without them, doodles are read
+// as aliens.
+func RegisterDoodles() {
+	Register(doodle.TypeNameModel, func(id int32) store.Store {
+		return doodle.NewModel(id)
+	})
+
+	Register(doodle.TypeNameNode, func(id int32) store.Store {
+		return doodle.NewNode(id)
+	})
+
+	Register(doodle.TypeNamePlate, func(id int32) store.Store {
+		return doodle.NewPlate(id)
+	})
+
+	Register(doodle.TypeNameView, func(id int32) store.Store {
+		return doodle.NewView(id)
+	})
 }
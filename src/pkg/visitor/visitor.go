@@ -1,29 +1,206 @@
 // Package visitor defines the Visitor pattern interface for traversing document trees.
 package visitor
 
+import (
+	"odcread/pkg/alien"
+	"odcread/pkg/fold"
+	"odcread/pkg/store"
+	"odcread/pkg/textmodel"
+)
+
+// Action tells Visit how to continue after a node has been visited.
+type Action int
+
+const (
+	Continue     Action = iota // Visit the node's children, then call its Leave method
+	SkipChildren               // Do not visit the node's children; its Leave method is not called
+	Stop                       // End the traversal
+)
+
 // Visitor defines the interface for traversing and processing document elements.
 // This is the Visitor role in the Visitor design pattern.
+//
+// Each node kind has its own method. Leave methods are called after the
+// children of a node whose Visit method returned Continue. Embed Base to
+// implement only the methods of interest.
 type Visitor interface {
-	// PartStart signals the beginning of a "part" (container element).
-	// This can be an StdTextModel, ViewPiece, or another container.
-	PartStart()
+	// VisitTextModel is called for a text model; its children are its pieces.
+	VisitTextModel(m *textmodel.StdTextModel) Action
+	LeaveTextModel(m *textmodel.StdTextModel)
+
+	// VisitShortPiece is called for a text piece with 8-bit characters (Latin-1).
+	VisitShortPiece(p *textmodel.ShortPiece) Action
+
+	// VisitLongPiece is called for a text piece with 16-bit characters (Unicode).
+	VisitLongPiece(p *textmodel.LongPiece) Action
+
+	// VisitViewPiece is called for a view embedded in text; its child is the view.
+	VisitViewPiece(p *textmodel.ViewPiece) Action
+	LeaveViewPiece(p *textmodel.ViewPiece)
+
+	// VisitFold is called for a fold; its child is the hidden part.
+	VisitFold(f *fold.Fold) Action
+	LeaveFold(f *fold.Fold)
+
+	// VisitAlien is called for a store of an unregistered type; its children are its components.
+	VisitAlien(a *alien.Alien) Action
+	LeaveAlien(a *alien.Alien)
+
+	// VisitAlienPiece is called for raw data inside an alien.
+	VisitAlienPiece(p *alien.AlienPiece) Action
+
+	// VisitAlienPart is called for a store inside an alien; its child is the store.
+	VisitAlienPart(p *alien.AlienPart) Action
+	LeaveAlienPart(p *alien.AlienPart)
+
+	// VisitStore is called for any other store; its children are the stores
+	// it embeds (see store.Container).
+	VisitStore(s store.Store) Action
+	LeaveStore(s store.Store)
+}
+
+// Base is a Visitor that does nothing and visits everything.
+type Base struct{}
 
-	// PartEnd signals the end of a "part".
-	PartEnd()
+func (Base) VisitTextModel(m *textmodel.StdTextModel) Action { return Continue }
+func (Base) LeaveTextModel(m *textmodel.StdTextModel)        {}
+func (Base) VisitShortPiece(p *textmodel.ShortPiece) Action  { return Continue }
+func (Base) VisitLongPiece(p *textmodel.LongPiece) Action    { return Continue }
+func (Base) VisitViewPiece(p *textmodel.ViewPiece) Action    { return Continue }
+func (Base) LeaveViewPiece(p *textmodel.ViewPiece)           {}
+func (Base) VisitFold(f *fold.Fold) Action                   { return Continue }
+func (Base) LeaveFold(f *fold.Fold)                          {}
+func (Base) VisitAlien(a *alien.Alien) Action                { return Continue }
+func (Base) LeaveAlien(a *alien.Alien)                       {}
+func (Base) VisitAlienPiece(p *alien.AlienPiece) Action      { return Continue }
+func (Base) VisitAlienPart(p *alien.AlienPart) Action        { return Continue }
+func (Base) LeaveAlienPart(p *alien.AlienPart)               {}
+func (Base) VisitStore(s store.Store) Action                 { return Continue }
+func (Base) LeaveStore(s store.Store)                        {}
 
-	// FoldLeft signals a left fold marker has been found.
-	// If collapsed is true, the first part that follows is the "hidden" part,
-	// otherwise the first part is the "alternative" text.
-	FoldLeft(collapsed bool)
+// Visit traverses the tree rooted at s in document order.
+// Stores that are reachable more than once (shared through LINK or NEWLINK,
+// possibly in a cycle) are visited only the first time they are reached.
+// It returns false if the visitor stopped the traversal.
+func Visit(s store.Store, v Visitor) bool {
+	return NewTraversal(v).Visit(s)
+}
+
+// Traversal visits trees with one visitor, visiting each store only the
+// first time it is reached in any of them. A visitor that visits some
+// stores itself (e.g. to write something between them) does so with the
+// traversal it is driven by, so that a store is still visited only once.
+type Traversal struct {
+	v    Visitor
+	seen map[store.Store]bool
+}
+
+// NewTraversal returns a traversal for v that has not reached any store.
+func NewTraversal(v Visitor) *Traversal {
+	return &Traversal{v: v, seen: make(map[store.Store]bool)}
+}
+
+// Visit traverses the tree rooted at s in document order, like the
+// function Visit, skipping the stores this traversal has already reached.
+// It returns false if the visitor stopped the traversal.
+func (t *Traversal) Visit(s store.Store) bool {
+	return t.store(s) != Stop
+}
 
-	// FoldRight signals a right fold marker has been found.
-	FoldRight()
+// store dispatches a store to the visitor method for its type.
+func (t *Traversal) store(s store.Store) Action {
+	if s == nil || t.seen[s] {
+		return Continue
+	}
+	t.seen[s] = true
 
-	// TextShortPiece processes a text piece with 8-bit characters (Latin-1).
-	// The piece parameter will be a *textmodel.ShortPiece that can be type-asserted.
-	TextShortPiece(piece interface{})
+	v := t.v
+	switch s := s.(type) {
+	case *textmodel.StdTextModel:
+		act := v.VisitTextModel(s)
+		if act != Continue {
+			return act
+		}
+		for _, piece := range s.GetPieces() {
+			if t.piece(piece) == Stop {
+				return Stop
+			}
+		}
+		v.LeaveTextModel(s)
+
+	case *fold.Fold:
+		act := v.VisitFold(s)
+		if act != Continue {
+			return act
+		}
+		if t.store(s.GetHidden()) == Stop {
+			return Stop
+		}
+		v.LeaveFold(s)
+
+	case *alien.Alien:
+		act := v.VisitAlien(s)
+		if act != Continue {
+			return act
+		}
+		for _, comp := range s.GetComponents() {
+			if t.component(comp) == Stop {
+				return Stop
+			}
+		}
+		v.LeaveAlien(s)
+
+	default:
+		act := v.VisitStore(s)
+		if act != Continue {
+			return act
+		}
+		if c, ok := s.(store.Container); ok {
+			for _, child := range c.Children() {
+				if t.store(child) == Stop {
+					return Stop
+				}
+			}
+		}
+		v.LeaveStore(s)
+	}
+	return Continue
+}
+
+// piece dispatches a text piece to the visitor method for its type.
+func (t *Traversal) piece(p textmodel.TextPiece) Action {
+	switch p := p.(type) {
+	case *textmodel.ShortPiece:
+		return t.v.VisitShortPiece(p)
+	case *textmodel.LongPiece:
+		return t.v.VisitLongPiece(p)
+	case *textmodel.ViewPiece:
+		act := t.v.VisitViewPiece(p)
+		if act != Continue {
+			return act
+		}
+		if t.store(p.GetView()) == Stop {
+			return Stop
+		}
+		t.v.LeaveViewPiece(p)
+	}
+	return Continue
+}
 
-	// TextLongPiece processes a text piece with 16-bit characters (Unicode).
-	// The piece parameter will be a *textmodel.LongPiece that can be type-asserted.
-	TextLongPiece(piece interface{})
+// component dispatches an alien component to the visitor method for its type.
+func (t *Traversal) component(c alien.AlienComponent) Action {
+	switch c := c.(type) {
+	case *alien.AlienPiece:
+		return t.v.VisitAlienPiece(c)
+	case *alien.AlienPart:
+		act := t.v.VisitAlienPart(c)
+		if act != Continue {
+			return act
+		}
+		if t.store(c.GetStore()) == Stop {
+			return Stop
+		}
+		t.v.LeaveAlienPart(c)
+	}
+	return Continue
 }
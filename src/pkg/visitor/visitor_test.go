@@ -0,0 +1,76 @@
+package visitor
+
+import (
+	"reflect"
+	"testing"
+
+	"odcread/pkg/alien"
+	"odcread/pkg/store"
+	"odcread/pkg/textmodel"
+)
+
+// recorder records the visited nodes and returns a fixed action for aliens.
+type recorder struct {
+	Base
+	calls       []string
+	alienAction Action
+}
+
+func (r *recorder) VisitTextModel(m *textmodel.StdTextModel) Action {
+	r.calls = append(r.calls, "text")
+	return Continue
+}
+
+func (r *recorder) LeaveTextModel(m *textmodel.StdTextModel) {
+	r.calls = append(r.calls, "/text")
+}
+
+func (r *recorder) VisitAlien(a *alien.Alien) Action {
+	r.calls = append(r.calls, "alien")
+	return r.alienAction
+}
+
+func (r *recorder) LeaveAlien(a *alien.Alien) {
+	r.calls = append(r.calls, "/alien")
+}
+
+func (r *recorder) VisitAlienPiece(p *alien.AlienPiece) Action {
+	r.calls = append(r.calls, "piece")
+	return Continue
+}
+
+func (r *recorder) VisitStore(s store.Store) Action {
+	r.calls = append(r.calls, s.GetTypeName())
+	return Stop
+}
+
+// tree builds an alien holding raw data, a text model and an Elem.
+func tree() store.Store {
+	a := alien.NewAlien(0, store.TypePath{"Foo.Bar^"})
+	a.AddComponent(alien.NewAlienPiece([]byte{1}))
+	a.AddComponent(alien.NewAlienPart(textmodel.NewStdTextModel(1)))
+	a.AddComponent(alien.NewAlienPart(store.NewElem(2)))
+	a.AddComponent(alien.NewAlienPart(textmodel.NewStdTextModel(3)))
+	return a
+}
+
+func TestVisit(t *testing.T) {
+	r := &recorder{alienAction: Continue}
+	if Visit(tree(), r) {
+		t.Error("Expected the traversal to be stopped")
+	}
+	want := []string{"alien", "piece", "text", "/text", store.TypeNameElem}
+	if !reflect.DeepEqual(r.calls, want) {
+		t.Errorf("Expected %v, got %v", want, r.calls)
+	}
+}
+
+func TestVisit_SkipChildren(t *testing.T) {
+	r := &recorder{alienAction: SkipChildren}
+	if !Visit(tree(), r) {
+		t.Error("Expected the traversal to complete")
+	}
+	if want := []string{"alien"}; !reflect.DeepEqual(r.calls, want) {
+		t.Errorf("Expected %v, got %v", want, r.calls)
+	}
+}
@@ -0,0 +1,197 @@
+package visitor
+
+import (
+	"fmt"
+	"strings"
+
+	"odcread/pkg/alien"
+	"odcread/pkg/fold"
+	"odcread/pkg/store"
+	"odcread/pkg/textmodel"
+)
+
+// Ancestry is the chain of stores from the root to a store, inclusive.
+// Stores inside an alien appear directly below it; views embedded in text
+// appear directly below the text model.
+type Ancestry []store.Store
+
+// Store returns the last store of the chain.
+func (a Ancestry) Store() store.Store {
+	if len(a) == 0 {
+		return nil
+	}
+	return a[len(a)-1]
+}
+
+// Parent returns the store containing the last store, or nil for the root.
+func (a Ancestry) Parent() store.Store {
+	if len(a) < 2 {
+		return nil
+	}
+	return a[len(a)-2]
+}
+
+// String returns the type names of the chain, e.g. "TextModels.StdModel^ > StdFolds.Fold^".
+func (a Ancestry) String() string {
+	names := make([]string, len(a))
+	for i, s := range a {
+		names[i] = s.GetTypeName()
+	}
+	return strings.Join(names, " > ")
+}
+
+// WalkFunc is called by Walk for every store. The ancestry is only valid
+// during the call. The returned Action controls the rest of the walk.
+type WalkFunc func(a Ancestry) Action
+
+// Walk calls fn for every store in the tree rooted at root, in document order.
+// Like Visit, it reaches every store once, even if links make it reachable
+// more than once or form a cycle. It returns false if fn stopped the walk.
+func Walk(root store.Store, fn WalkFunc) bool {
+	return Visit(root, &walker{fn: fn})
+}
+
+// walker adapts a WalkFunc to the Visitor interface.
+type walker struct {
+	Base
+	fn   WalkFunc
+	path Ancestry
+}
+
+func (w *walker) enter(s store.Store) Action {
+	w.path = append(w.path, s)
+	act := w.fn(w.path)
+	if act != Continue {
+		w.leave()
+	}
+	return act
+}
+
+func (w *walker) leave() {
+	w.path = w.path[:len(w.path)-1]
+}
+
+func (w *walker) VisitTextModel(m *textmodel.StdTextModel) Action { return w.enter(m) }
+func (w *walker) LeaveTextModel(m *textmodel.StdTextModel)        { w.leave() }
+func (w *walker) VisitFold(f *fold.Fold) Action                   { return w.enter(f) }
+func (w *walker) LeaveFold(f *fold.Fold)                          { w.leave() }
+func (w *walker) VisitAlien(a *alien.Alien) Action                { return w.enter(a) }
+func (w *walker) LeaveAlien(a *alien.Alien)                       { w.leave() }
+func (w *walker) VisitStore(s store.Store) Action                 { return w.enter(s) }
+func (w *walker) LeaveStore(s store.Store)                        { w.leave() }
+
+// Selector decides whether a store, given its ancestry, is selected.
+type Selector func(a Ancestry) bool
+
+// Find returns the ancestry of every store selected by sel, in document order.
+func Find(root store.Store, sel Selector) []Ancestry {
+	var found []Ancestry
+	Walk(root, func(a Ancestry) Action {
+		if sel(a) {
+			found = append(found, append(Ancestry(nil), a...))
+		}
+		return Continue
+	})
+	return found
+}
+
+// Type selects stores whose type name or type path contains name,
+// e.g. Type("Views.View^") selects all views. "*" selects every store.
+func Type(name string) Selector {
+	return func(a Ancestry) bool {
+		return hasType(a.Store(), name)
+	}
+}
+
+// hasType reports whether s is of the named type or one of its extensions.
+func hasType(s store.Store, name string) bool {
+	if name == "*" || s.GetTypeName() == name {
+		return true
+	}
+	for _, t := range s.GetTypePath() {
+		if t == name {
+			return true
+		}
+	}
+	return false
+}
+
+// Inside selects stores that have an ancestor selected by sel.
+func Inside(sel Selector) Selector {
+	return func(a Ancestry) bool {
+		for i := len(a) - 1; i > 0; i-- {
+			if sel(a[:i]) {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+// Child selects stores whose parent is selected by sel.
+func Child(sel Selector) Selector {
+	return func(a Ancestry) bool {
+		return len(a) > 1 && sel(a[:len(a)-1])
+	}
+}
+
+// And selects stores selected by all of sels.
+func And(sels ...Selector) Selector {
+	return func(a Ancestry) bool {
+		for _, sel := range sels {
+			if !sel(a) {
+				return false
+			}
+		}
+		return true
+	}
+}
+
+// Or selects stores selected by any of sels.
+func Or(sels ...Selector) Selector {
+	return func(a Ancestry) bool {
+		for _, sel := range sels {
+			if sel(a) {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+// Query parses a path-like query of type names. As in CSS, "A B" selects
+// stores of type B nested anywhere inside a store of type A, and "A > B"
+// stores of type B directly inside one of type A. For example,
+// "StdFolds.Fold^ TextModels.StdModel^" selects text models inside folds.
+func Query(q string) (Selector, error) {
+	fields := strings.Fields(q)
+	if len(fields) == 0 {
+		return nil, fmt.Errorf("empty query")
+	}
+
+	var sel Selector
+	child := false
+	for _, f := range fields {
+		if f == ">" {
+			if sel == nil || child {
+				return nil, fmt.Errorf("misplaced '>' in query %q", q)
+			}
+			child = true
+			continue
+		}
+		step := Type(f)
+		switch {
+		case sel == nil:
+			sel = step
+		case child:
+			sel = And(step, Child(sel))
+		default:
+			sel = And(step, Inside(sel))
+		}
+		child = false
+	}
+	if child {
+		return nil, fmt.Errorf("query %q ends with '>'", q)
+	}
+	return sel, nil
+}
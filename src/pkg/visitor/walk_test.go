@@ -0,0 +1,67 @@
+package visitor
+
+import (
+	"testing"
+
+	"odcread/pkg/alien"
+	"odcread/pkg/store"
+	"odcread/pkg/textmodel"
+)
+
+// cyclicTree builds an alien that contains a text model, an Elem and itself.
+func cyclicTree() *alien.Alien {
+	a := alien.NewAlien(0, store.TypePath{"Foo.Bar^", "Stores.Store^"})
+	a.AddComponent(alien.NewAlienPart(textmodel.NewStdTextModel(1)))
+	a.AddComponent(alien.NewAlienPart(a))
+	a.AddComponent(alien.NewAlienPart(store.NewElem(2)))
+	return a
+}
+
+func TestWalk_Cycle(t *testing.T) {
+	var names []string
+	Walk(cyclicTree(), func(a Ancestry) Action {
+		names = append(names, a.String())
+		return Continue
+	})
+	want := []string{
+		"Stores.Store^",
+		"Stores.Store^ > TextModels.StdModel^",
+		"Stores.Store^ > Stores.Elem^",
+	}
+	if len(names) != len(want) {
+		t.Fatalf("Expected %v, got %v", want, names)
+	}
+	for i := range want {
+		if names[i] != want[i] {
+			t.Errorf("Store %d: expected %q, got %q", i, want[i], names[i])
+		}
+	}
+}
+
+func TestFind(t *testing.T) {
+	root := cyclicTree()
+	found := Find(root, Type(textmodel.TypeNameStdTextModel))
+	if len(found) != 1 {
+		t.Fatalf("Expected 1 text model, got %d", len(found))
+	}
+	if found[0].Parent() != root {
+		t.Errorf("Expected the alien as parent, got %v", found[0].Parent())
+	}
+
+	sel, err := Query("Foo.Bar^ > Stores.Elem^")
+	if err != nil {
+		t.Fatalf("Query failed: %v", err)
+	}
+	if found := Find(root, sel); len(found) != 1 || found[0].Store().GetID() != 2 {
+		t.Errorf("Expected the Elem, got %v", found)
+	}
+
+	sel, _ = Query("Stores.Elem^ *")
+	if found := Find(root, sel); len(found) != 0 {
+		t.Errorf("Expected nothing inside the Elem, got %v", found)
+	}
+
+	if _, err := Query("Foo.Bar^ >"); err == nil {
+		t.Error("Expected error for trailing '>'")
+	}
+}